@@ -4,7 +4,9 @@ import type {
     ClientDetailResponse,
     ClientListResponse,
     ClientResponse,
+    ClientSecretListResponse,
     CreateClientRequest,
+    RegenerateSecretRequest,
     UpdateClientRequest,
 } from "./client.dao";
 
@@ -71,10 +73,14 @@ export const clientApi = {
         }
     },
 
-    regenerateSecret: async (id: string): Promise<APIResponse<ClientDetailResponse>> => {
+    regenerateSecret: async (
+        id: string,
+        data: RegenerateSecretRequest = {}
+    ): Promise<APIResponse<ClientDetailResponse>> => {
         try {
             const response = await API.post<APIResponse<ClientDetailResponse>>(
-                `/clients/${id}/regenerate-secret`
+                `/clients/${id}/regenerate-secret`,
+                data
             );
             return response.data;
         } catch (error) {
@@ -82,6 +88,28 @@ export const clientApi = {
         }
     },
     
+    getSecrets: async (id: string): Promise<APIResponse<ClientSecretListResponse>> => {
+        try {
+            const response = await API.get<APIResponse<ClientSecretListResponse>>(
+                `/clients/${id}/secrets`
+            );
+            return response.data;
+        } catch (error) {
+            throw handleApiError<ClientSecretListResponse>(error);
+        }
+    },
+
+    revokeSecret: async (id: string, secretId: number): Promise<APIResponse<null>> => {
+        try {
+            const response = await API.delete<APIResponse<null>>(
+                `/clients/${id}/secrets/${secretId}`
+            );
+            return response.data;
+        } catch (error) {
+            throw handleApiError<null>(error);
+        }
+    },
+
     regenerateSecretByClientID: async (clientId: string): Promise<APIResponse<ClientDetailResponse>> => {
         try {
             const response = await API.post<APIResponse<ClientDetailResponse>>(
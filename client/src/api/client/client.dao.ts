@@ -2,24 +2,46 @@ export interface CreateClientRequest {
     name: string;
     description: string;
     website?: string;
-    redirect_uri: string;
+    redirect_uris: string[];
+    allow_loopback_redirect_ports?: boolean;
+    allowed_origins?: string[];
     is_public: boolean;
     oidc_enabled: boolean;
     allowed_scopes?: string[];
     allowed_grant_types?: string[];
     allowed_response_types?: string[];
+    userinfo_signed_response_alg?: string;
+    post_logout_redirect_uris?: string[];
+    backchannel_logout_uri?: string;
+    frontchannel_logout_uri?: string;
+    frontchannel_logout_session_required?: boolean;
+    is_first_party?: boolean;
+    token_endpoint_auth_method?: string;
+    jwks?: string;
+    jwks_uri?: string;
 }
 
 export interface UpdateClientRequest {
     name: string;
     description: string;
     website?: string;
-    redirect_uri: string;
+    redirect_uris: string[];
+    allow_loopback_redirect_ports?: boolean;
+    allowed_origins?: string[];
     is_public: boolean;
     oidc_enabled: boolean;
     allowed_scopes?: string[];
     allowed_grant_types?: string[];
     allowed_response_types?: string[];
+    userinfo_signed_response_alg?: string;
+    post_logout_redirect_uris?: string[];
+    backchannel_logout_uri?: string;
+    frontchannel_logout_uri?: string;
+    frontchannel_logout_session_required?: boolean;
+    is_first_party?: boolean;
+    token_endpoint_auth_method?: string;
+    jwks?: string;
+    jwks_uri?: string;
 }
 
 export interface ClientResponse {
@@ -28,18 +50,50 @@ export interface ClientResponse {
     name: string;
     description: string;
     website: string;
-    redirect_uri: string;
+    redirect_uris: string[];
+    allow_loopback_redirect_ports: boolean;
+    allowed_origins: string[];
     is_public: boolean;
     oidc_enabled: boolean;
     allowed_scopes: string[];
     allowed_grant_types: string[];
     allowed_response_types: string[];
+    userinfo_signed_response_alg?: string;
+    post_logout_redirect_uris: string[];
+    backchannel_logout_uri?: string;
+    frontchannel_logout_uri?: string;
+    frontchannel_logout_session_required: boolean;
+    is_first_party: boolean;
+    token_endpoint_auth_method: string;
+    jwks?: string;
+    jwks_uri?: string;
     created_at: string;
     updated_at: string;
 }
 
 export interface ClientDetailResponse extends ClientResponse {
     client_secret: string;
+    client_secret_expires_at?: string;
+}
+
+export interface RegenerateSecretRequest {
+    // Seconds the current secret keeps working after the new one is issued
+    grace_period?: number;
+    // Seconds until the new secret expires
+    expires_in?: number;
+}
+
+export interface ClientSecretResponse {
+    id: number;
+    active: boolean;
+    created_at: string;
+    last_used_at?: string;
+    expires_at?: string;
+}
+
+export interface ClientSecretListResponse {
+    secrets: ClientSecretResponse[];
+    total: number;
 }
 
 export interface ClientListResponse {
@@ -1,7 +1,7 @@
 import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
 import { clientApi } from "./client.api";
 import { toast } from "sonner";
-import type { CreateClientRequest, UpdateClientRequest } from "./client.dao";
+import type { CreateClientRequest, RegenerateSecretRequest, UpdateClientRequest } from "./client.dao";
 
 // Query key constants
 export const clientKeys = {
@@ -10,6 +10,7 @@ export const clientKeys = {
   list: (filters: Record<string, any>) => [...clientKeys.lists(), { filters }] as const,
   details: () => [...clientKeys.all, "detail"] as const,
   detail: (id: string) => [...clientKeys.details(), id] as const,
+  secrets: (id: string) => [...clientKeys.detail(id), "secrets"] as const,
 };
 
 // Hook to get all clients
@@ -29,6 +30,15 @@ export const useGetClientById = (id: string) => {
   });
 };
 
+// Hook to get the secrets of a client, without the secrets themselves
+export const useGetClientSecrets = (id: string) => {
+  return useQuery({
+    queryKey: clientKeys.secrets(id),
+    queryFn: () => clientApi.getSecrets(id),
+    enabled: !!id,
+  });
+};
+
 // Hook to create a new client
 export const useCreateClient = () => {
   const queryClient = useQueryClient();
@@ -91,9 +101,9 @@ export const useRegenerateClientSecret = (id: string) => {
   const queryClient = useQueryClient();
   
   return useMutation({
-    mutationFn: () => clientApi.regenerateSecret(id),
+    mutationFn: (data?: RegenerateSecretRequest) => clientApi.regenerateSecret(id, data),
     onSuccess: (_data) => {
-      // Invalidate the specific client query
+      // Invalidate the specific client query and its secrets
       queryClient.invalidateQueries({ queryKey: clientKeys.detail(id) });
       toast.success("Client secret regenerated successfully");
     },
@@ -104,6 +114,23 @@ export const useRegenerateClientSecret = (id: string) => {
   });
 };
 
+// Hook to revoke one secret of a client before it expires
+export const useRevokeClientSecret = (id: string) => {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (secretId: number) => clientApi.revokeSecret(id, secretId),
+    onSuccess: (_data) => {
+      queryClient.invalidateQueries({ queryKey: clientKeys.secrets(id) });
+      toast.success("Client secret revoked successfully");
+    },
+    onError: (error) => {
+      console.error("Client secret revocation error:", error);
+      toast.error("Failed to revoke client secret");
+    },
+  });
+};
+
 // Hook to regenerate a client secret using client_id
 export const useRegenerateClientSecretByClientID = () => {
   const queryClient = useQueryClient();
@@ -17,7 +17,7 @@ import { Input } from "@/components/ui/input"
 import { cn } from "@/lib/utils"
 import { Link, useNavigate } from "@tanstack/react-router"
 import Logo from "../logo"
-import { APP_NAME, APP_VERSION } from "@/utils/config"
+import { API_BASE_URL, APP_NAME, APP_VERSION } from "@/utils/config"
 import { useLogin } from "@/api/auth/auth.query"
 import { toast } from "sonner"
 import type { APIResponse } from "@/api/api"
@@ -28,10 +28,22 @@ const formSchema = z.object({
   password: z.string(),
 })
 
+// Only follow redirects back to the CentralAuth server itself,
+// e.g. the OAuth authorization endpoint that sent the user here
+function isTrustedRedirect(url: string) {
+  try {
+    return new URL(url).origin === new URL(API_BASE_URL).origin
+  } catch {
+    return false
+  }
+}
+
 export function LoginForm({
   className,
+  redirectTo,
+  loginHint,
   ...props
-}: React.ComponentProps<"div">) {
+}: React.ComponentProps<"div"> & { redirectTo?: string; loginHint?: string }) {
 
   const navigate = useNavigate()
   const login = useLogin()
@@ -41,7 +53,8 @@ export function LoginForm({
   const form = useForm<z.infer<typeof formSchema>>({
     resolver: zodResolver(formSchema),
     defaultValues: {
-      email: "",
+      // Clients can suggest the account to sign in with through login_hint
+      email: loginHint ?? "",
       password: "",
     },
   })
@@ -62,6 +75,12 @@ export function LoginForm({
       // Show success message
       toast.success(`Welcome back!`);
 
+      // Return to the authorization request that required this login
+      if (redirectTo && isTrustedRedirect(redirectTo)) {
+        window.location.assign(redirectTo);
+        return;
+      }
+
       // Check if there's a redirect path stored
       const redirectPath = sessionStorage.getItem('redirect_after_login');
       if (redirectPath) {
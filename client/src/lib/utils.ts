@@ -4,3 +4,20 @@ import { twMerge } from "tailwind-merge";
 export function cn(...inputs: ClassValue[]) {
 	return twMerge(clsx(inputs));
 }
+
+// Splits a textarea holding one entry per line, dropping blank lines
+export function splitLines(value: string): string[] {
+	return value.split("\n").map((line) => line.trim()).filter(Boolean);
+}
+
+// Reports whether every line of a textarea is an absolute URL
+export function everyLineIsURL(value: string): boolean {
+	return splitLines(value).every((line) => {
+		try {
+			new URL(line);
+			return true;
+		} catch {
+			return false;
+		}
+	});
+}
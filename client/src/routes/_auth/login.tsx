@@ -1,12 +1,22 @@
 import { LoginForm } from '@/components/auth/login-form'
 import { createFileRoute } from '@tanstack/react-router'
 
+type LoginSearch = {
+  redirect?: string
+  login_hint?: string
+}
+
 export const Route = createFileRoute('/_auth/login')({
+  validateSearch: (search: Record<string, unknown>): LoginSearch => ({
+    redirect: typeof search.redirect === 'string' ? search.redirect : undefined,
+    login_hint: typeof search.login_hint === 'string' ? search.login_hint : undefined,
+  }),
   component: RouteComponent,
 })
 
 function RouteComponent() {
+  const { redirect, login_hint } = Route.useSearch()
   return <div>
-    <LoginForm className="mx-auto max-w-sm" />
+    <LoginForm className="mx-auto max-w-sm" redirectTo={redirect} loginHint={login_hint} />
   </div>
 }
@@ -3,9 +3,12 @@ import { useAuthStore } from '@/stores/useAuthStore'
 import { createFileRoute, Link, Outlet, redirect } from '@tanstack/react-router'
 
 export const Route = createFileRoute('/_auth')({
-  beforeLoad: async () => {
+  beforeLoad: async ({ location }) => {
     const isAuthenticated = useAuthStore.getState().isAuthenticated
-    if (isAuthenticated) {
+    // Authorization requests redirect here when the server has no session,
+    // so the form must be shown even if the local store still looks signed in
+    const hasRedirect = Boolean((location.search as { redirect?: string }).redirect)
+    if (isAuthenticated && !hasRedirect) {
       // Redirect to the last visited page or dashboard
       throw redirect({
         to: '/',
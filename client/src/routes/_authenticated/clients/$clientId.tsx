@@ -1,4 +1,4 @@
-import { useGetClientById, useRegenerateClientSecret, useUpdateClient } from '@/api/client/client.query';
+import { useGetClientById, useGetClientSecrets, useRegenerateClientSecret, useRevokeClientSecret, useUpdateClient } from '@/api/client/client.query';
 import Header from '@/components/Header';
 import {
     AlertDialog,
@@ -24,14 +24,16 @@ import {
     FormMessage,
 } from '@/components/ui/form';
 import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
 import { Separator } from '@/components/ui/separator';
 import { Skeleton } from '@/components/ui/skeleton';
 import { Switch } from '@/components/ui/switch';
 import { Textarea } from '@/components/ui/textarea';
 import { Heading, Text } from '@/components/ui/typography';
+import { everyLineIsURL, splitLines } from '@/lib/utils';
 import { zodResolver } from '@hookform/resolvers/zod';
 import { createFileRoute, Link, useNavigate } from '@tanstack/react-router';
-import { ArrowLeft, ClipboardCopy, KeyRound, Save } from 'lucide-react';
+import { ArrowLeft, ClipboardCopy, KeyRound, Save, Trash2 } from 'lucide-react';
 import { useState } from 'react';
 import { useForm } from 'react-hook-form';
 import { toast } from 'sonner';
@@ -45,12 +47,25 @@ const clientFormSchema = z.object({
     name: z.string().min(3, 'Name must be at least 3 characters').max(100, 'Name cannot exceed 100 characters'),
     description: z.string().max(500, 'Description cannot exceed 500 characters').optional(),
     website: z.string().url('Please enter a valid URL').max(255, 'Website URL cannot exceed 255 characters').optional().or(z.literal('')),
-    redirect_uri: z.string().url('Please enter a valid redirect URI').max(255, 'Redirect URI cannot exceed 255 characters'),
+    redirect_uris: z.string()
+        .refine((value) => splitLines(value).length > 0, 'At least one redirect URI is required')
+        .refine(everyLineIsURL, 'Each line must be a valid redirect URI'),
+    allow_loopback_redirect_ports: z.boolean(),
+    allowed_origins: z.string().refine(everyLineIsURL, 'Each line must be a valid origin'),
     is_public: z.boolean(),
     oidc_enabled: z.boolean(),
     allowed_scopes: z.array(z.string()),
     allowed_grant_types: z.array(z.string()),
     allowed_response_types: z.array(z.string()),
+    userinfo_signed_response_alg: z.string(),
+    post_logout_redirect_uris: z.array(z.string()),
+    backchannel_logout_uri: z.string(),
+    frontchannel_logout_uri: z.string(),
+    frontchannel_logout_session_required: z.boolean(),
+    is_first_party: z.boolean(),
+    token_endpoint_auth_method: z.string(),
+    jwks: z.string(),
+    jwks_uri: z.string(),
 });
 
 type ClientFormValues = z.infer<typeof clientFormSchema>;
@@ -60,8 +75,12 @@ function ClientDetailPage() {
     const { data, isLoading, isError } = useGetClientById(clientId);
     const updateClient = useUpdateClient(clientId);
     const regenerateSecret = useRegenerateClientSecret(clientId);
+    const { data: secretsData } = useGetClientSecrets(clientId);
+    const revokeSecret = useRevokeClientSecret(clientId);
     const navigate = useNavigate();
     const [isRegenerateSecretDialogOpen, setIsRegenerateSecretDialogOpen] = useState(false);
+    // Hours the current secret keeps working after it is regenerated
+    const [gracePeriodHours, setGracePeriodHours] = useState(0);
 
     const form = useForm<ClientFormValues>({
         resolver: zodResolver(clientFormSchema),
@@ -69,23 +88,45 @@ function ClientDetailPage() {
             name: '',
             description: '',
             website: '',
-            redirect_uri: '',
+            redirect_uris: '',
+            allow_loopback_redirect_ports: false,
+            allowed_origins: '',
             is_public: false,
             oidc_enabled: false,
             allowed_scopes: [],
             allowed_grant_types: [],
             allowed_response_types: [],
+            userinfo_signed_response_alg: '',
+            post_logout_redirect_uris: [],
+            backchannel_logout_uri: '',
+            frontchannel_logout_uri: '',
+            frontchannel_logout_session_required: false,
+            is_first_party: false,
+            token_endpoint_auth_method: 'client_secret_basic',
+            jwks: '',
+            jwks_uri: '',
         },
         values: data?.data ? {
             name: data.data.name,
             description: data.data.description || '',
             website: data.data.website || '',
-            redirect_uri: data.data.redirect_uri,
+            redirect_uris: (data.data.redirect_uris || []).join('\n'),
+            allow_loopback_redirect_ports: data.data.allow_loopback_redirect_ports || false,
+            allowed_origins: (data.data.allowed_origins || []).join('\n'),
             is_public: data.data.is_public,
             oidc_enabled: data.data.oidc_enabled,
             allowed_scopes: data.data.allowed_scopes || [],
             allowed_grant_types: data.data.allowed_grant_types || [],
             allowed_response_types: data.data.allowed_response_types || [],
+            userinfo_signed_response_alg: data.data.userinfo_signed_response_alg || '',
+            post_logout_redirect_uris: data.data.post_logout_redirect_uris || [],
+            backchannel_logout_uri: data.data.backchannel_logout_uri || '',
+            frontchannel_logout_uri: data.data.frontchannel_logout_uri || '',
+            frontchannel_logout_session_required: data.data.frontchannel_logout_session_required || false,
+            is_first_party: data.data.is_first_party || false,
+            token_endpoint_auth_method: data.data.is_public ? 'client_secret_basic' : data.data.token_endpoint_auth_method,
+            jwks: data.data.jwks || '',
+            jwks_uri: data.data.jwks_uri || '',
         } : undefined,
     });
 
@@ -95,12 +136,29 @@ function ClientDetailPage() {
                 name: values.name,
                 description: values.description || '',
                 website: values.website || '',
-                redirect_uri: values.redirect_uri,
+                redirect_uris: splitLines(values.redirect_uris),
+                allow_loopback_redirect_ports: values.allow_loopback_redirect_ports,
+                allowed_origins: splitLines(values.allowed_origins),
                 is_public: values.is_public,
                 oidc_enabled: values.oidc_enabled,
                 allowed_scopes: values.allowed_scopes,
                 allowed_grant_types: values.allowed_grant_types,
                 allowed_response_types: values.allowed_response_types,
+                userinfo_signed_response_alg: values.userinfo_signed_response_alg,
+                post_logout_redirect_uris: values.post_logout_redirect_uris,
+                backchannel_logout_uri: values.backchannel_logout_uri,
+                frontchannel_logout_uri: values.frontchannel_logout_uri,
+                frontchannel_logout_session_required: values.frontchannel_logout_session_required,
+                is_first_party: values.is_first_party,
+                // Public clients do not authenticate, and only private_key_jwt uses keys
+                ...(!values.is_public && {
+                    token_endpoint_auth_method: values.token_endpoint_auth_method,
+                    ...(values.token_endpoint_auth_method === 'private_key_jwt' && {
+                        // A JWKS URI takes the place of inline keys
+                        jwks: values.jwks_uri ? '' : values.jwks,
+                        jwks_uri: values.jwks_uri,
+                    }),
+                }),
             });
         } catch (error) {
             console.error('Failed to update client:', error);
@@ -109,8 +167,11 @@ function ClientDetailPage() {
 
     const handleRegenerateSecret = async () => {
         try {
-            const result = await regenerateSecret.mutateAsync();
+            const result = await regenerateSecret.mutateAsync({
+                grace_period: Math.max(0, Math.round(gracePeriodHours * 3600)),
+            });
             setIsRegenerateSecretDialogOpen(false);
+            setGracePeriodHours(0);
 
             if (result?.data?.client_secret) {
                 // Show the new secret in a toast with a copy button
@@ -302,15 +363,55 @@ function ClientDetailPage() {
 
                                         <FormField
                                             control={form.control}
-                                            name="redirect_uri"
+                                            name="redirect_uris"
+                                            render={({ field }) => (
+                                                <FormItem>
+                                                    <FormLabel>Redirect URIs</FormLabel>
+                                                    <FormControl>
+                                                        <Textarea placeholder="https://example.com/callback" {...field} />
+                                                    </FormControl>
+                                                    <FormDescription>
+                                                        Where users may be redirected after authorization, one per line. Each must match exactly.
+                                                    </FormDescription>
+                                                    <FormMessage />
+                                                </FormItem>
+                                            )}
+                                        />
+
+                                        <FormField
+                                            control={form.control}
+                                            name="allow_loopback_redirect_ports"
+                                            render={({ field }) => (
+                                                <FormItem className="flex flex-row items-center justify-between rounded-lg border p-4">
+                                                    <div className="space-y-0.5">
+                                                        <FormLabel className="text-base">
+                                                            Allow Loopback Redirect Ports
+                                                        </FormLabel>
+                                                        <FormDescription>
+                                                            Native apps may use any port of a registered http://127.0.0.1 or http://[::1] redirect URI
+                                                        </FormDescription>
+                                                    </div>
+                                                    <FormControl>
+                                                        <Switch
+                                                            checked={field.value}
+                                                            onCheckedChange={field.onChange}
+                                                        />
+                                                    </FormControl>
+                                                </FormItem>
+                                            )}
+                                        />
+
+                                        <FormField
+                                            control={form.control}
+                                            name="allowed_origins"
                                             render={({ field }) => (
                                                 <FormItem>
-                                                    <FormLabel>Redirect URI</FormLabel>
+                                                    <FormLabel>Allowed Web Origins</FormLabel>
                                                     <FormControl>
-                                                        <Input placeholder="https://example.com/callback" {...field} />
+                                                        <Textarea placeholder="https://example.com" {...field} />
                                                     </FormControl>
                                                     <FormDescription>
-                                                        Where users will be redirected after authorization
+                                                        Origins whose browser apps may call the token and userinfo endpoints, one per line
                                                     </FormDescription>
                                                     <FormMessage />
                                                 </FormItem>
@@ -341,6 +442,79 @@ function ClientDetailPage() {
                                         )}
                                     />
 
+                                    {!form.watch("is_public") && (
+                                        <div className="space-y-4">
+                                            <FormField
+                                                control={form.control}
+                                                name="token_endpoint_auth_method"
+                                                render={({ field }) => (
+                                                    <FormItem>
+                                                        <FormLabel>Token Endpoint Authentication</FormLabel>
+                                                        <FormControl>
+                                                            <select
+                                                                className="border-input dark:bg-input/30 flex h-9 w-full rounded-md border bg-transparent px-3 py-1 text-base shadow-xs outline-none focus-visible:border-ring focus-visible:ring-ring/50 focus-visible:ring-[3px] md:text-sm"
+                                                                {...field}
+                                                            >
+                                                                <option value="client_secret_basic">Client secret (HTTP Basic)</option>
+                                                                <option value="client_secret_post">Client secret (form parameters)</option>
+                                                                <option value="client_secret_jwt">JWT signed with the client secret</option>
+                                                                <option value="private_key_jwt">JWT signed with a private key</option>
+                                                            </select>
+                                                        </FormControl>
+                                                        <FormDescription>
+                                                            {field.value === 'client_secret_jwt'
+                                                                ? 'Regenerate the secret after switching to this method, secrets issued before cannot verify JWTs.'
+                                                                : 'How the client proves its identity when calling the token endpoint.'}
+                                                        </FormDescription>
+                                                        <FormMessage />
+                                                    </FormItem>
+                                                )}
+                                            />
+
+                                            {form.watch("token_endpoint_auth_method") === 'private_key_jwt' && (
+                                                <>
+                                                    <FormField
+                                                        control={form.control}
+                                                        name="jwks_uri"
+                                                        render={({ field }) => (
+                                                            <FormItem>
+                                                                <FormLabel>JWKS URI</FormLabel>
+                                                                <FormControl>
+                                                                    <Input placeholder="https://example.com/.well-known/jwks.json" {...field} />
+                                                                </FormControl>
+                                                                <FormDescription>
+                                                                    URL publishing the client's public keys. Leave empty to register the keys below.
+                                                                </FormDescription>
+                                                                <FormMessage />
+                                                            </FormItem>
+                                                        )}
+                                                    />
+                                                    <FormField
+                                                        control={form.control}
+                                                        name="jwks"
+                                                        render={({ field }) => (
+                                                            <FormItem>
+                                                                <FormLabel>JWKS</FormLabel>
+                                                                <FormControl>
+                                                                    <Textarea
+                                                                        placeholder='{"keys": [...]}'
+                                                                        className="font-mono text-xs"
+                                                                        disabled={!!form.watch("jwks_uri")}
+                                                                        {...field}
+                                                                    />
+                                                                </FormControl>
+                                                                <FormDescription>
+                                                                    JSON Web Key Set with the client's public keys
+                                                                </FormDescription>
+                                                                <FormMessage />
+                                                            </FormItem>
+                                                        )}
+                                                    />
+                                                </>
+                                            )}
+                                        </div>
+                                    )}
+
                                     <Separator className="my-6" />
                                     
                                     <div className="space-y-4">
@@ -462,6 +636,7 @@ function ClientDetailPage() {
                                                                         { id: "authorization_code", label: "Authorization Code" },
                                                                         { id: "refresh_token", label: "Refresh Token" },
                                                                         { id: "client_credentials", label: "Client Credentials" },
+                                                                        { id: "urn:ietf:params:oauth:grant-type:device_code", label: "Device Code" },
                                                                         { id: "password", label: "Password" },
                                                                         { id: "implicit", label: "Implicit (legacy)" },
                                                                     ].map((grant) => (
@@ -626,6 +801,37 @@ function ClientDetailPage() {
                                         <Text className="text-xs text-muted-foreground mb-4">
                                             The client secret is only shown once when created or regenerated. Keep it secure.
                                         </Text>
+                                        {(secretsData?.data?.secrets ?? []).map((secret) => (
+                                            <div key={secret.id} className="flex items-start justify-between gap-2 mb-3">
+                                                <div>
+                                                    <Badge variant={secret.active ? "secondary" : "outline"} className="text-xs mb-1">
+                                                        {secret.active ? (secret.expires_at ? 'Expiring' : 'Active') : 'Expired'}
+                                                    </Badge>
+                                                    <Text className="text-xs text-muted-foreground">
+                                                        Created {new Date(secret.created_at).toLocaleString()}
+                                                    </Text>
+                                                    <Text className="text-xs text-muted-foreground">
+                                                        {secret.last_used_at
+                                                            ? `Last used ${new Date(secret.last_used_at).toLocaleString()}`
+                                                            : 'Never used'}
+                                                    </Text>
+                                                    {secret.expires_at && (
+                                                        <Text className="text-xs text-muted-foreground">
+                                                            Expires {new Date(secret.expires_at).toLocaleString()}
+                                                        </Text>
+                                                    )}
+                                                </div>
+                                                <Button
+                                                    variant="ghost"
+                                                    size="sm"
+                                                    onClick={() => revokeSecret.mutate(secret.id)}
+                                                    disabled={revokeSecret.isPending}
+                                                    title="Revoke this secret"
+                                                >
+                                                    <Trash2 className="h-4 w-4" />
+                                                </Button>
+                                            </div>
+                                        ))}
                                         <Button
                                             variant="outline"
                                             onClick={() => setIsRegenerateSecretDialogOpen(true)}
@@ -660,11 +866,25 @@ function ClientDetailPage() {
                     <AlertDialogHeader>
                         <AlertDialogTitle>Regenerate Client Secret?</AlertDialogTitle>
                         <AlertDialogDescription>
-                            This will invalidate the current client secret and generate a new one. Any applications using the current secret will need to be updated.
+                            This will generate a new client secret. Without a grace period the current secret stops working immediately, and any applications using it will need to be updated.
                             <br /><br />
                             <strong>This action cannot be undone.</strong>
                         </AlertDialogDescription>
                     </AlertDialogHeader>
+                    <div className="space-y-2">
+                        <Label htmlFor="grace-period">Grace period (hours)</Label>
+                        <Input
+                            id="grace-period"
+                            type="number"
+                            min={0}
+                            max={720}
+                            value={gracePeriodHours}
+                            onChange={(e) => setGracePeriodHours(Number(e.target.value) || 0)}
+                        />
+                        <Text className="text-xs text-muted-foreground">
+                            How long the current secret keeps working while your applications are redeployed, up to 30 days.
+                        </Text>
+                    </div>
                     <AlertDialogFooter>
                         <AlertDialogCancel>Cancel</AlertDialogCancel>
                         <AlertDialogAction onClick={handleRegenerateSecret}>
@@ -59,10 +59,10 @@ function ClientListPage() {
       ),
     },
     {
-      accessorKey: 'redirect_uri',
-      header: 'Redirect URI',
+      accessorKey: 'redirect_uris',
+      header: 'Redirect URIs',
       cell: ({ row }) => (
-        <Text className="truncate max-w-[250px]">{row.original.redirect_uri}</Text>
+        <Text className="truncate max-w-[250px]">{row.original.redirect_uris.join(', ')}</Text>
       ),
     },
     {
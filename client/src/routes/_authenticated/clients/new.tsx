@@ -15,6 +15,7 @@ import {
 import { Input } from '@/components/ui/input';
 import { Switch } from '@/components/ui/switch';
 import { Textarea } from '@/components/ui/textarea';
+import { everyLineIsURL, splitLines } from '@/lib/utils';
 import { zodResolver } from '@hookform/resolvers/zod';
 import { createFileRoute, Link, useNavigate } from '@tanstack/react-router';
 import { ArrowLeft, Save } from 'lucide-react';
@@ -30,7 +31,10 @@ const clientFormSchema = z.object({
   name: z.string().min(3, 'Name must be at least 3 characters').max(100, 'Name cannot exceed 100 characters'),
   description: z.string().max(500, 'Description cannot exceed 500 characters').optional(),
   website: z.string().url('Please enter a valid URL').max(255, 'Website URL cannot exceed 255 characters').optional().or(z.literal('')),
-  redirect_uri: z.string().url('Please enter a valid redirect URI').max(255, 'Redirect URI cannot exceed 255 characters'),
+  redirect_uris: z.string()
+    .refine((value) => splitLines(value).length > 0, 'At least one redirect URI is required')
+    .refine(everyLineIsURL, 'Each line must be a valid redirect URI'),
+  allowed_origins: z.string().refine(everyLineIsURL, 'Each line must be a valid origin'),
   is_public: z.boolean(),
   oidc_enabled: z.boolean().default(false),
   allowed_scopes: z.array(z.string()).default([]),
@@ -50,7 +54,8 @@ function CreateClientPage() {
       name: '',
       description: '',
       website: '',
-      redirect_uri: '',
+      redirect_uris: '',
+      allowed_origins: '',
       is_public: false,
       oidc_enabled: false,
       allowed_scopes: [],
@@ -65,7 +70,8 @@ function CreateClientPage() {
         name: values.name,
         description: values.description || '',
         website: values.website || '',
-        redirect_uri: values.redirect_uri,
+        redirect_uris: splitLines(values.redirect_uris),
+        allowed_origins: splitLines(values.allowed_origins),
         is_public: values.is_public,
         oidc_enabled: values.oidc_enabled,
         allowed_scopes: values.allowed_scopes,
@@ -184,15 +190,32 @@ function CreateClientPage() {
 
                 <FormField
                   control={form.control}
-                  name="redirect_uri"
+                  name="redirect_uris"
                   render={({ field }) => (
                     <FormItem>
-                      <FormLabel>Redirect URI</FormLabel>
+                      <FormLabel>Redirect URIs</FormLabel>
                       <FormControl>
-                        <Input placeholder="https://example.com/callback" {...field} />
+                        <Textarea placeholder="https://example.com/callback" {...field} />
                       </FormControl>
                       <FormDescription>
-                        Where users will be redirected after authorization
+                        Where users may be redirected after authorization, one per line. Each must match exactly.
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+
+                <FormField
+                  control={form.control}
+                  name="allowed_origins"
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>Allowed Web Origins</FormLabel>
+                      <FormControl>
+                        <Textarea placeholder="https://example.com" {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        Origins whose browser apps may call the token and userinfo endpoints, one per line
                       </FormDescription>
                       <FormMessage />
                     </FormItem>
@@ -340,6 +363,7 @@ function CreateClientPage() {
                               { id: "authorization_code", label: "Authorization Code" },
                               { id: "refresh_token", label: "Refresh Token" },
                               { id: "client_credentials", label: "Client Credentials" },
+                              { id: "urn:ietf:params:oauth:grant-type:device_code", label: "Device Code" },
                               { id: "password", label: "Password" },
                               { id: "implicit", label: "Implicit (legacy)" },
                             ].map((grant) => (
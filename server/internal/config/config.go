@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
@@ -19,6 +20,7 @@ type Config struct {
 	ClientURL      string // URL of the client application for CORS
 	DB             db.Config
 	JWT            JWTConfig
+	OIDC           OIDCConfig
 	AdminEmail     string // Email address that automatically gets admin role and permissions
 }
 
@@ -27,7 +29,49 @@ type JWTConfig struct {
 	Secret             string
 	ExpiryHours        int
 	RefreshSecret      string
-	RefreshExpiryHours int // Changed from RefreshHours to RefreshExpiryHours for consistency
+	RefreshExpiryHours int           // Changed from RefreshHours to RefreshExpiryHours for consistency
+	SigningAlgorithm   string        // RS256, ES256, EdDSA, or HS256 (legacy, signs with Secret)
+	PrivateKeyFile     string        // PEM private key for asymmetric algorithms
+	KeyEncryptionKey   string        // Passphrase encrypting signing keys stored in the database
+	KeyRotationPeriod  time.Duration // How long a signing key stays active before it is rotated
+	KeyActivationDelay time.Duration // How long a new key is published in the JWKS before it signs tokens
+	KeySyncInterval    time.Duration // How often replicas reload keys and check the rotation schedule
+}
+
+// OIDCConfig holds OpenID Connect provider related configuration
+type OIDCConfig struct {
+	Issuer              string        // Public base URL of this provider, used as the iss claim
+	AuthCodeExpiry      time.Duration // Lifetime of authorization codes issued by /oauth2/authorize
+	AccessTokenExpiry   time.Duration // Lifetime of access tokens issued by /oauth2/token
+	RefreshTokenExpiry  time.Duration // Lifetime of refresh tokens issued by /oauth2/token
+	IDTokenExpiry       time.Duration // Lifetime of ID tokens issued by /oauth2/token
+	DeviceCodeExpiry    time.Duration // Lifetime of device codes issued by /oauth2/device_authorization
+	DevicePollInterval  time.Duration // Minimum time devices wait between token requests
+	PushedRequestExpiry time.Duration // Lifetime of request URIs issued by /oauth2/par
+
+	RequestObjectEncryptionKeyFile string // PEM RSA private key clients may encrypt request objects to
+
+	BackchannelLogoutTimeout     time.Duration // Timeout of one logout token delivery to a client
+	BackchannelLogoutBackoff     time.Duration // Delay before the first retry, doubled after each failure
+	BackchannelLogoutMaxAttempts int           // Deliveries are given up after this many failures
+
+	Registration RegistrationConfig
+}
+
+// Modes of dynamic client registration at /oauth2/register
+const (
+	RegistrationDisabled = "disabled" // Clients are only created by administrators
+	RegistrationToken    = "token"    // Registration requires the initial access token
+	RegistrationOpen     = "open"     // Anyone may register a client
+)
+
+// RegistrationConfig is the policy for clients registering themselves (RFC 7591)
+type RegistrationConfig struct {
+	Mode               string   // RegistrationDisabled, RegistrationToken or RegistrationOpen
+	InitialAccessToken string   // Bearer token authorizing registration in token mode
+	GrantTypes         []string // Grant types self-registered clients may use
+	Scopes             []string // Scopes self-registered clients may request
+	RedirectHosts      []string // Hosts of the URIs they may register, *.example.com matches subdomains; empty allows any
 }
 
 // NewConfig creates a new configuration with default values or from environment variables
@@ -60,6 +104,30 @@ func NewConfig() *Config {
 			ExpiryHours:        24, // 1 day
 			RefreshSecret:      "your-refresh-secret-key-change-in-production",
 			RefreshExpiryHours: 168, // 7 days
+			SigningAlgorithm:   "RS256",
+			KeyRotationPeriod:  90 * 24 * time.Hour, // 90 days
+			KeyActivationDelay: time.Hour,
+			KeySyncInterval:    time.Minute,
+		},
+		OIDC: OIDCConfig{
+			Issuer:              "http://localhost:8080",
+			AuthCodeExpiry:      5 * time.Minute,
+			AccessTokenExpiry:   1 * time.Hour,
+			RefreshTokenExpiry:  30 * 24 * time.Hour, // 30 days
+			IDTokenExpiry:       1 * time.Hour,
+			DeviceCodeExpiry:    10 * time.Minute,
+			DevicePollInterval:  5 * time.Second,
+			PushedRequestExpiry: 5 * time.Minute,
+
+			BackchannelLogoutTimeout:     5 * time.Second,
+			BackchannelLogoutBackoff:     30 * time.Second,
+			BackchannelLogoutMaxAttempts: 6,
+
+			Registration: RegistrationConfig{
+				Mode:       RegistrationDisabled,
+				GrantTypes: []string{"authorization_code", "refresh_token"},
+				Scopes:     []string{"openid", "profile", "email"},
+			},
 		},
 	}
 
@@ -131,9 +199,117 @@ func NewConfig() *Config {
 		config.JWT.RefreshExpiryHours = jwtRefreshHours // Changed from RefreshHours to RefreshExpiryHours
 	}
 
+	if jwtSigningAlg := os.Getenv("JWT_SIGNING_ALG"); jwtSigningAlg != "" {
+		config.JWT.SigningAlgorithm = jwtSigningAlg
+	}
+
+	if jwtPrivateKeyFile := os.Getenv("JWT_PRIVATE_KEY_FILE"); jwtPrivateKeyFile != "" {
+		config.JWT.PrivateKeyFile = jwtPrivateKeyFile
+	}
+
+	if keyEncryptionKey := os.Getenv("JWT_KEY_ENCRYPTION_KEY"); keyEncryptionKey != "" {
+		config.JWT.KeyEncryptionKey = keyEncryptionKey
+	}
+
+	if keyRotationPeriod := getEnvAsDuration("JWT_KEY_ROTATION_PERIOD", 90*24*time.Hour); keyRotationPeriod != 0 {
+		config.JWT.KeyRotationPeriod = keyRotationPeriod
+	}
+
+	if keyActivationDelay := getEnvAsDuration("JWT_KEY_ACTIVATION_DELAY", time.Hour); keyActivationDelay != 0 {
+		config.JWT.KeyActivationDelay = keyActivationDelay
+	}
+
+	if keySyncInterval := getEnvAsDuration("JWT_KEY_SYNC_INTERVAL", time.Minute); keySyncInterval != 0 {
+		config.JWT.KeySyncInterval = keySyncInterval
+	}
+
+	// OIDC config from environment
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		config.OIDC.Issuer = strings.TrimSuffix(issuer, "/")
+	}
+
+	if authCodeExpiry := getEnvAsDuration("OIDC_AUTH_CODE_EXPIRY", 5*time.Minute); authCodeExpiry != 0 {
+		config.OIDC.AuthCodeExpiry = authCodeExpiry
+	}
+
+	if accessTokenExpiry := getEnvAsDuration("OIDC_ACCESS_TOKEN_EXPIRY", time.Hour); accessTokenExpiry != 0 {
+		config.OIDC.AccessTokenExpiry = accessTokenExpiry
+	}
+
+	if refreshTokenExpiry := getEnvAsDuration("OIDC_REFRESH_TOKEN_EXPIRY", 30*24*time.Hour); refreshTokenExpiry != 0 {
+		config.OIDC.RefreshTokenExpiry = refreshTokenExpiry
+	}
+
+	if idTokenExpiry := getEnvAsDuration("OIDC_ID_TOKEN_EXPIRY", time.Hour); idTokenExpiry != 0 {
+		config.OIDC.IDTokenExpiry = idTokenExpiry
+	}
+
+	if deviceCodeExpiry := getEnvAsDuration("OIDC_DEVICE_CODE_EXPIRY", 10*time.Minute); deviceCodeExpiry != 0 {
+		config.OIDC.DeviceCodeExpiry = deviceCodeExpiry
+	}
+
+	if devicePollInterval := getEnvAsDuration("OIDC_DEVICE_POLL_INTERVAL", 5*time.Second); devicePollInterval != 0 {
+		config.OIDC.DevicePollInterval = devicePollInterval
+	}
+
+	if pushedRequestExpiry := getEnvAsDuration("OIDC_PAR_EXPIRY", 5*time.Minute); pushedRequestExpiry != 0 {
+		config.OIDC.PushedRequestExpiry = pushedRequestExpiry
+	}
+
+	config.OIDC.RequestObjectEncryptionKeyFile = os.Getenv("OIDC_REQUEST_OBJECT_ENCRYPTION_KEY_FILE")
+
+	if backchannelTimeout := getEnvAsDuration("OIDC_BACKCHANNEL_LOGOUT_TIMEOUT", 5*time.Second); backchannelTimeout != 0 {
+		config.OIDC.BackchannelLogoutTimeout = backchannelTimeout
+	}
+
+	if backchannelBackoff := getEnvAsDuration("OIDC_BACKCHANNEL_LOGOUT_BACKOFF", 30*time.Second); backchannelBackoff != 0 {
+		config.OIDC.BackchannelLogoutBackoff = backchannelBackoff
+	}
+
+	if backchannelMaxAttempts := getEnvAsInt("OIDC_BACKCHANNEL_LOGOUT_MAX_ATTEMPTS", 6); backchannelMaxAttempts != 0 {
+		config.OIDC.BackchannelLogoutMaxAttempts = backchannelMaxAttempts
+	}
+
+	if registrationMode := os.Getenv("OIDC_REGISTRATION_MODE"); registrationMode != "" {
+		config.OIDC.Registration.Mode = registrationMode
+	}
+	config.OIDC.Registration.InitialAccessToken = os.Getenv("OIDC_REGISTRATION_INITIAL_ACCESS_TOKEN")
+	switch config.OIDC.Registration.Mode {
+	case RegistrationDisabled, RegistrationOpen:
+	case RegistrationToken:
+		if config.OIDC.Registration.InitialAccessToken == "" {
+			log.Println("Warning: OIDC_REGISTRATION_MODE is token but OIDC_REGISTRATION_INITIAL_ACCESS_TOKEN is not set, disabling client registration")
+			config.OIDC.Registration.Mode = RegistrationDisabled
+		}
+	default:
+		log.Printf("Warning: unknown OIDC_REGISTRATION_MODE %q, disabling client registration", config.OIDC.Registration.Mode)
+		config.OIDC.Registration.Mode = RegistrationDisabled
+	}
+
+	if grantTypes := getEnvAsList("OIDC_REGISTRATION_GRANT_TYPES"); grantTypes != nil {
+		config.OIDC.Registration.GrantTypes = grantTypes
+	}
+
+	if scopes := getEnvAsList("OIDC_REGISTRATION_SCOPES"); scopes != nil {
+		config.OIDC.Registration.Scopes = scopes
+	}
+
+	config.OIDC.Registration.RedirectHosts = getEnvAsList("OIDC_REGISTRATION_REDIRECT_HOSTS")
+
 	return config
 }
 
+// getEnvAsList splits a comma-separated environment variable, returning nil when it is unset or empty
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getEnvAsDuration tries to parse an environment variable as a duration
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
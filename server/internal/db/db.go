@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -22,10 +23,15 @@ type Config struct {
 	SSLMode  string
 }
 
+// SessionEndHook is notified after sessions of a user have ended.
+// sessionID is not valid when all sessions of the user ended at once.
+type SessionEndHook func(ctx context.Context, userID int32, sessionID sql.NullInt32)
+
 // Store provides database access to the application
 type Store struct {
 	*sqlc.Queries
-	db *sql.DB
+	db              *sql.DB
+	sessionEndHooks []SessionEndHook
 }
 
 // NewStore creates a new database store
@@ -41,6 +47,73 @@ func (s *Store) GetDB() *sql.DB {
 	return s.db
 }
 
+// ExecTx runs fn inside a database transaction.
+// The transaction is rolled back if fn returns an error and committed otherwise.
+func (s *Store) ExecTx(ctx context.Context, fn func(*sqlc.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("transaction error: %v, rollback error: %w", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// OnSessionEnd registers a hook run whenever sessions end through LogoutSession,
+// RevokeSession or RevokeAllUserSessions. Hooks must be registered at startup.
+func (s *Store) OnSessionEnd(hook SessionEndHook) {
+	s.sessionEndHooks = append(s.sessionEndHooks, hook)
+}
+
+// LogoutSession marks a session as logged out and runs the session end hooks
+func (s *Store) LogoutSession(ctx context.Context, id int32) error {
+	return s.endSession(ctx, id, s.Queries.LogoutSession)
+}
+
+// RevokeSession revokes a session and runs the session end hooks
+func (s *Store) RevokeSession(ctx context.Context, id int32) error {
+	return s.endSession(ctx, id, s.Queries.RevokeSession)
+}
+
+// RevokeAllUserSessions revokes every session of a user and runs the session end hooks
+func (s *Store) RevokeAllUserSessions(ctx context.Context, userID int32) error {
+	if err := s.Queries.RevokeAllUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	s.runSessionEndHooks(ctx, userID, sql.NullInt32{})
+	return nil
+}
+
+// endSession ends a session with the given query, running the hooks only when
+// the session was still active
+func (s *Store) endSession(ctx context.Context, id int32, end func(context.Context, int32) error) error {
+	session, err := s.Queries.GetSessionByID(ctx, id)
+	active := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if err := end(ctx, id); err != nil {
+		return err
+	}
+	if active {
+		s.runSessionEndHooks(ctx, session.UserID, sql.NullInt32{Int32: id, Valid: true})
+	}
+	return nil
+}
+
+func (s *Store) runSessionEndHooks(ctx context.Context, userID int32, sessionID sql.NullInt32) {
+	for _, hook := range s.sessionEndHooks {
+		hook(ctx, userID, sessionID)
+	}
+}
+
 // Connect establishes a database connection
 func Connect(config Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Record when the user authenticated so ID tokens can carry auth_time
+ALTER TABLE oidc_auth_codes
+    ADD COLUMN auth_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_auth_codes
+    DROP COLUMN IF EXISTS auth_time;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Keys used to sign tokens, shared by every server replica.
+-- Private keys are stored as AES-GCM encrypted PKCS#8 DER.
+-- Lifecycle: pending -> active -> retiring -> retired
+CREATE TABLE signing_keys (
+    id SERIAL PRIMARY KEY,
+    kid VARCHAR(255) NOT NULL UNIQUE,
+    algorithm VARCHAR(20) NOT NULL,
+    private_key BYTEA NOT NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'active', 'retiring', 'retired')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    retiring_at TIMESTAMP WITH TIME ZONE,
+    retired_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Only one key signs new tokens at a time
+CREATE UNIQUE INDEX idx_signing_keys_single_active ON signing_keys(state) WHERE state = 'active';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS signing_keys;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Algorithm the client wants UserInfo responses signed with; NULL returns plain JSON
+ALTER TABLE clients
+    ADD COLUMN userinfo_signed_response_alg VARCHAR(20);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS userinfo_signed_response_alg;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Access tokens can be revoked together with their refresh token family
+ALTER TABLE oidc_access_tokens
+    ADD COLUMN revoked BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Refreshed ID tokens keep the time the user originally authenticated
+ALTER TABLE oidc_refresh_tokens
+    ADD COLUMN auth_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_refresh_tokens
+    DROP COLUMN IF EXISTS auth_time;
+
+ALTER TABLE oidc_access_tokens
+    DROP COLUMN IF EXISTS revoked;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Tokens issued by the client_credentials grant have no user
+ALTER TABLE oidc_access_tokens
+    ALTER COLUMN user_id DROP NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM oidc_access_tokens WHERE user_id IS NULL;
+
+ALTER TABLE oidc_access_tokens
+    ALTER COLUMN user_id SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Link OIDC codes and tokens to the CentralAuth session they were issued from,
+-- so revoking the session also invalidates them. NULL for client-only tokens
+-- and tokens issued before this migration.
+ALTER TABLE oidc_auth_codes
+    ADD COLUMN session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE;
+
+ALTER TABLE oidc_access_tokens
+    ADD COLUMN session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE;
+
+ALTER TABLE oidc_refresh_tokens
+    ADD COLUMN session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_oidc_access_tokens_session_id ON oidc_access_tokens(session_id);
+CREATE INDEX idx_oidc_refresh_tokens_session_id ON oidc_refresh_tokens(session_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_refresh_tokens DROP COLUMN IF EXISTS session_id;
+ALTER TABLE oidc_access_tokens DROP COLUMN IF EXISTS session_id;
+ALTER TABLE oidc_auth_codes DROP COLUMN IF EXISTS session_id;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- URIs a client may ask to return to after RP-initiated logout
+ALTER TABLE clients
+    ADD COLUMN post_logout_redirect_uris TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS post_logout_redirect_uris;
+-- +goose StatementEnd
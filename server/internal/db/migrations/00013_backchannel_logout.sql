@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Endpoint receiving logout tokens (OpenID Connect Back-Channel Logout 1.0)
+ALTER TABLE clients
+    ADD COLUMN backchannel_logout_uri TEXT;
+
+-- Logout tokens queued for delivery to relying parties, kept as an audit trail
+CREATE TABLE backchannel_logout_deliveries (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    logout_uri TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id INTEGER, -- sid of the ended session; NULL when all sessions of the user ended
+    jti VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_backchannel_logout_deliveries_due ON backchannel_logout_deliveries(next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX idx_backchannel_logout_deliveries_client_id ON backchannel_logout_deliveries(client_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS backchannel_logout_deliveries;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS backchannel_logout_uri;
+-- +goose StatementEnd
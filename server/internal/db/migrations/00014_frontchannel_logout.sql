@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Page loaded in an iframe when a user's session ends (OpenID Connect Front-Channel Logout 1.0);
+-- iss and sid are appended to it when the client requires them
+ALTER TABLE clients
+    ADD COLUMN frontchannel_logout_uri TEXT,
+    ADD COLUMN frontchannel_logout_session_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS frontchannel_logout_uri,
+    DROP COLUMN IF EXISTS frontchannel_logout_session_required;
+-- +goose StatementEnd
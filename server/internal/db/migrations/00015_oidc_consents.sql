@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- First-party clients are operated by CentralAuth itself and are authorized without a consent prompt
+ALTER TABLE clients
+    ADD COLUMN is_first_party BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Scopes a user granted to a third-party client; later authorizations
+-- covered by them skip the consent prompt
+CREATE TABLE oidc_consents (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (client_id, user_id)
+);
+
+CREATE INDEX idx_oidc_consents_user_id ON oidc_consents(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS oidc_consents;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS is_first_party;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Authentication methods used to sign the session in (RFC 8176), from which the
+-- authentication context class reported to clients in the acr claim is derived
+ALTER TABLE sessions
+    ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{pwd}';
+
+-- The authentication context of the session is carried along so that ID tokens
+-- issued from a code or refresh token report acr and amr
+ALTER TABLE oidc_auth_codes
+    ADD COLUMN acr VARCHAR(255),
+    ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE oidc_refresh_tokens
+    ADD COLUMN acr VARCHAR(255),
+    ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_refresh_tokens
+    DROP COLUMN IF EXISTS acr,
+    DROP COLUMN IF EXISTS amr;
+
+ALTER TABLE oidc_auth_codes
+    DROP COLUMN IF EXISTS acr,
+    DROP COLUMN IF EXISTS amr;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS amr;
+-- +goose StatementEnd
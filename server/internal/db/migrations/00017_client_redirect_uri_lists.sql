@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Clients register every redirect URI and web origin they use (e.g. one per deployment).
+-- Redirect URIs match exactly, except that the port of a loopback redirect URI may
+-- vary when the client opts in (RFC 8252 section 7.3)
+ALTER TABLE clients
+    ADD COLUMN redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN allowed_origins TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN allow_loopback_redirect_ports BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE clients
+SET redirect_uris = ARRAY[redirect_uri]
+WHERE redirect_uri <> '';
+
+ALTER TABLE clients
+    DROP COLUMN redirect_uri;
+
+-- Origins are looked up on every cross-origin request to the provider endpoints
+CREATE INDEX idx_clients_allowed_origins ON clients USING GIN (allowed_origins);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_clients_allowed_origins;
+
+ALTER TABLE clients
+    ADD COLUMN redirect_uri VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Only the first redirect URI of each client can be kept
+UPDATE clients
+SET redirect_uri = COALESCE(redirect_uris[1], '');
+
+ALTER TABLE clients
+    ALTER COLUMN redirect_uri DROP DEFAULT,
+    DROP COLUMN IF EXISTS redirect_uris,
+    DROP COLUMN IF EXISTS allowed_origins,
+    DROP COLUMN IF EXISTS allow_loopback_redirect_ports;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Client secrets are stored as salted SHA-256 hashes in the form
+-- sha256$<hex salt>$<hex digest of salt || secret>, see utils.HashClientSecret
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE clients RENAME COLUMN client_secret TO client_secret_hash;
+
+-- Hash the secrets that are still stored in plaintext, each with its own salt
+UPDATE clients
+SET client_secret_hash = 'sha256$' || encode(salted.salt, 'hex') || '$' ||
+    encode(digest(salted.salt || convert_to(clients.client_secret_hash, 'UTF8'), 'sha256'), 'hex')
+FROM (
+    SELECT id, gen_random_bytes(16) AS salt FROM clients
+) AS salted
+WHERE salted.id = clients.id
+AND clients.client_secret_hash NOT LIKE 'sha256$%';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Hashed secrets cannot be recovered, clients have to regenerate them after a rollback
+ALTER TABLE clients RENAME COLUMN client_secret_hash TO client_secret;
+
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A client can hold more than one secret while it rotates them: the previous
+-- secret keeps working until its expiry so that running instances can be redeployed.
+-- Secrets without an expiry are valid until they are rotated or revoked.
+CREATE TABLE client_secrets (
+    id SERIAL PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    secret_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_client_secrets_client_id ON client_secrets(client_id);
+
+INSERT INTO client_secrets (client_id, secret_hash, created_at)
+SELECT client_id, client_secret_hash, updated_at FROM clients;
+
+ALTER TABLE clients
+    DROP COLUMN client_secret_hash;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    ADD COLUMN client_secret_hash VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Only the newest secret of each client can be kept
+UPDATE clients
+SET client_secret_hash = newest.secret_hash
+FROM (
+    SELECT DISTINCT ON (client_id) client_id, secret_hash
+    FROM client_secrets
+    ORDER BY client_id, created_at DESC
+) AS newest
+WHERE newest.client_id = clients.client_id;
+
+ALTER TABLE clients
+    ALTER COLUMN client_secret_hash DROP DEFAULT;
+
+DROP TABLE IF EXISTS client_secrets;
+-- +goose StatementEnd
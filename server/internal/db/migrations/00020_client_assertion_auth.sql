@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- How a client authenticates at the token, introspection and revocation endpoints
+-- (OpenID Connect Core section 9). The JWT methods verify a signed assertion: with the
+-- client's registered public keys for private_key_jwt, with its secret for client_secret_jwt.
+ALTER TABLE clients
+    ADD COLUMN token_endpoint_auth_method VARCHAR(50) NOT NULL DEFAULT 'client_secret_basic'
+        CHECK (token_endpoint_auth_method IN ('client_secret_basic', 'client_secret_post', 'client_secret_jwt', 'private_key_jwt', 'none')),
+    ADD COLUMN jwks TEXT,
+    ADD COLUMN jwks_uri VARCHAR(255);
+
+UPDATE clients
+SET token_endpoint_auth_method = 'none'
+WHERE is_public;
+
+-- client_secret_jwt needs the secret itself to check the HMAC, so the secrets of those
+-- clients are also kept encrypted with the server's key encryption key
+ALTER TABLE client_secrets
+    ADD COLUMN secret_encrypted BYTEA;
+
+-- Identifiers of the assertions clients authenticated with, kept until the assertions
+-- expire so that each of them is accepted only once (RFC 7523 section 3)
+CREATE TABLE client_assertion_jtis (
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    jti VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (client_id, jti)
+);
+
+CREATE INDEX idx_client_assertion_jtis_expires_at ON client_assertion_jtis(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_assertion_jtis;
+
+ALTER TABLE client_secrets
+    DROP COLUMN IF EXISTS secret_encrypted;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS token_endpoint_auth_method,
+    DROP COLUMN IF EXISTS jwks,
+    DROP COLUMN IF EXISTS jwks_uri;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Device authorization requests (RFC 8628). The device polls the token endpoint with
+-- the device code while the user approves the request by entering the user code
+-- on another device. Approval fills in the user and the session it came from.
+CREATE TABLE oidc_device_codes (
+    id SERIAL PRIMARY KEY,
+    device_code VARCHAR(255) NOT NULL UNIQUE,
+    user_code VARCHAR(16) NOT NULL UNIQUE,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'denied', 'consumed')),
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE,
+    auth_time TIMESTAMP WITH TIME ZONE,
+    acr VARCHAR(255),
+    amr TEXT[] NOT NULL DEFAULT '{}',
+    poll_interval INTEGER NOT NULL,
+    last_polled_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_oidc_device_codes_client_id ON oidc_device_codes(client_id);
+CREATE INDEX idx_oidc_device_codes_expires_at ON oidc_device_codes(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS oidc_device_codes;
+-- +goose StatementEnd
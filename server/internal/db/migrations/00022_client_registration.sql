@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Clients that registered themselves at /oauth2/register (RFC 7591) manage their own
+-- registration with a registration access token (RFC 7592). Only its hash is stored,
+-- in the same format as client secrets; administrator-created clients have none.
+ALTER TABLE clients
+    ADD COLUMN registration_access_token_hash VARCHAR(255);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS registration_access_token_hash;
+-- +goose StatementEnd
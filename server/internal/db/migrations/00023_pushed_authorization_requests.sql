@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Pushed authorization requests (RFC 9126). A client posts its authorization parameters
+-- to /oauth2/par and sends the user agent to /oauth2/authorize with the returned
+-- request_uri only, so the parameters never pass through the browser.
+CREATE TABLE oidc_pushed_authorization_requests (
+    id SERIAL PRIMARY KEY,
+    request_uri VARCHAR(255) NOT NULL UNIQUE,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    -- The authorization parameters, form-urlencoded
+    parameters TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_oidc_pushed_authorization_requests_expires_at ON oidc_pushed_authorization_requests(expires_at);
+
+-- Clients that must push their authorization requests instead of sending them through the browser
+ALTER TABLE clients
+    ADD COLUMN require_pushed_authorization_requests BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS require_pushed_authorization_requests;
+
+DROP TABLE IF EXISTS oidc_pushed_authorization_requests;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- JWT-secured authorization requests (RFC 9101). Clients can send their authorization
+-- parameters as a signed request object, either by value or by reference.
+ALTER TABLE clients
+    -- Clients whose authorization requests must be signed request objects
+    ADD COLUMN require_signed_request_object BOOLEAN NOT NULL DEFAULT FALSE,
+    -- URLs the server may fetch request objects of the client from
+    ADD COLUMN request_uris TEXT[] NOT NULL DEFAULT '{}';
+
+-- Pushed requests whose parameters came from a verified request object
+ALTER TABLE oidc_pushed_authorization_requests
+    ADD COLUMN from_request_object BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_pushed_authorization_requests
+    DROP COLUMN IF EXISTS from_request_object;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS request_uris,
+    DROP COLUMN IF EXISTS require_signed_request_object;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- OAuth 2.0 Token Exchange (RFC 8693). A client exchanges an access token of a user for
+-- a narrower one aimed at a downstream service, which it then calls on the user's behalf.
+ALTER TABLE clients
+    -- Audiences and resources the client may exchange tokens into
+    ADD COLUMN token_exchange_audiences TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE oidc_access_tokens
+    -- Services the token is intended for, empty when it is not restricted
+    ADD COLUMN audience TEXT[] NOT NULL DEFAULT '{}',
+    -- The act claim of an exchanged token as JSON, naming the party acting for the subject
+    ADD COLUMN actor TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_access_tokens
+    DROP COLUMN IF EXISTS actor,
+    DROP COLUMN IF EXISTS audience;
+
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS token_exchange_audiences;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A client can exchange a token another client obtained on its own behalf. The
+-- exchanged token keeps that client as subject, while client_id names the client
+-- holding it.
+ALTER TABLE oidc_access_tokens
+    ADD COLUMN subject_client_id VARCHAR(255);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_access_tokens
+    DROP COLUMN IF EXISTS subject_client_id;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Opaque identifiers of sessions for the OpenID provider endpoints. Relying parties
+-- send the user agent there on cross-site navigations, so the cookie holding the
+-- identifier is SameSite=Lax; it cannot be used as a credential anywhere else,
+-- unlike the session tokens.
+CREATE TABLE op_sessions (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    identifier VARCHAR(255) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_op_sessions_session_id ON op_sessions(session_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS op_sessions;
+-- +goose StatementEnd
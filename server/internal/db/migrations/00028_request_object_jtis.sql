@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Identifiers of the request objects clients sent, kept until the request objects
+-- expire so that each of them is accepted only once (RFC 9101 section 10.8)
+CREATE TABLE request_object_jtis (
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    jti VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (client_id, jti)
+);
+
+CREATE INDEX idx_request_object_jtis_expires_at ON request_object_jtis(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS request_object_jtis;
+-- +goose StatementEnd
@@ -0,0 +1,72 @@
+-- name: ListBackchannelLogoutClients :many
+-- Clients with a back-channel logout URI that hold live tokens of the user,
+-- limited to the tokens of one session when session_id is set
+SELECT * FROM clients
+WHERE backchannel_logout_uri IS NOT NULL
+AND (
+    EXISTS (
+        SELECT 1 FROM oidc_access_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.user_id = sqlc.arg(user_id)
+        AND (sqlc.narg(session_id)::int IS NULL OR t.session_id = sqlc.narg(session_id))
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+    OR EXISTS (
+        SELECT 1 FROM oidc_refresh_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.user_id = sqlc.arg(user_id)
+        AND (sqlc.narg(session_id)::int IS NULL OR t.session_id = sqlc.narg(session_id))
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+);
+
+-- name: CreateBackchannelLogoutDelivery :one
+INSERT INTO backchannel_logout_deliveries (
+    client_id,
+    logout_uri,
+    user_id,
+    session_id,
+    jti
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ClaimDueBackchannelLogoutDeliveries :many
+-- Leases the pending deliveries that are due, so each is sent by one replica only
+UPDATE backchannel_logout_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)::timestamptz,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT id FROM backchannel_logout_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(batch_size)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkBackchannelLogoutDelivered :exec
+UPDATE backchannel_logout_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_error = NULL,
+    delivered_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RecordBackchannelLogoutFailure :exec
+UPDATE backchannel_logout_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    last_error = $3,
+    next_attempt_at = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListBackchannelLogoutDeliveriesByClient :many
+SELECT * FROM backchannel_logout_deliveries
+WHERE client_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
@@ -1,18 +1,32 @@
 -- name: CreateClient :one
 INSERT INTO clients (
     client_id,
-    client_secret,
     name,
     description,
     website,
-    redirect_uri,
+    redirect_uris,
     is_public,
     oidc_enabled,
     allowed_scopes,
     allowed_grant_types,
-    allowed_response_types
+    allowed_response_types,
+    userinfo_signed_response_alg,
+    post_logout_redirect_uris,
+    backchannel_logout_uri,
+    frontchannel_logout_uri,
+    frontchannel_logout_session_required,
+    is_first_party,
+    allowed_origins,
+    allow_loopback_redirect_ports,
+    token_endpoint_auth_method,
+    jwks,
+    jwks_uri,
+    require_pushed_authorization_requests,
+    require_signed_request_object,
+    request_uris,
+    token_exchange_audiences
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 ) RETURNING *;
 
 -- name: GetClientByID :one
@@ -23,6 +37,14 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM clients
 WHERE client_id = $1 LIMIT 1;
 
+-- name: IsClientAllowedOrigin :one
+-- Whether a web origin is registered by any OIDC client
+SELECT EXISTS (
+    SELECT 1 FROM clients
+    WHERE allowed_origins @> ARRAY[sqlc.arg(origin)::text]
+    AND oidc_enabled = true
+);
+
 -- name: ListClients :many
 SELECT * FROM clients
 ORDER BY created_at DESC;
@@ -33,24 +55,37 @@ SET
     name = $2,
     description = $3,
     website = $4,
-    redirect_uri = $5,
+    redirect_uris = $5,
     is_public = $6,
     oidc_enabled = $7,
     allowed_scopes = $8,
     allowed_grant_types = $9,
     allowed_response_types = $10,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING *;
-
--- name: UpdateClientSecret :one
-UPDATE clients
-SET
-    client_secret = $2,
+    userinfo_signed_response_alg = $11,
+    post_logout_redirect_uris = $12,
+    backchannel_logout_uri = $13,
+    frontchannel_logout_uri = $14,
+    frontchannel_logout_session_required = $15,
+    is_first_party = $16,
+    allowed_origins = $17,
+    allow_loopback_redirect_ports = $18,
+    token_endpoint_auth_method = $19,
+    jwks = $20,
+    jwks_uri = $21,
+    require_pushed_authorization_requests = $22,
+    require_signed_request_object = $23,
+    request_uris = $24,
+    token_exchange_audiences = $25,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING *;
 
 -- name: DeleteClient :exec
 DELETE FROM clients
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: SetClientRegistrationAccessToken :exec
+-- Stores the hash of the token a self-registered client manages its registration with
+UPDATE clients
+SET registration_access_token_hash = $2
+WHERE client_id = $1;
@@ -0,0 +1,17 @@
+-- name: RecordClientAssertionJTI :execrows
+-- Records the jti of an assertion, affecting no row when it was already used.
+-- An expired entry is reused, the assertion it belonged to cannot be replayed anymore.
+INSERT INTO client_assertion_jtis (
+    client_id,
+    jti,
+    expires_at
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (client_id, jti) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+WHERE client_assertion_jtis.expires_at < NOW();
+
+-- name: DeleteExpiredClientAssertionJTIs :exec
+DELETE FROM client_assertion_jtis
+WHERE expires_at < NOW();
@@ -0,0 +1,57 @@
+-- name: LockClientSecrets :exec
+-- Serializes secret rotations of a client for the current transaction
+SELECT pg_advisory_xact_lock(hashtext('client_secrets:' || sqlc.arg(client_id)::text));
+
+-- name: CreateClientSecret :one
+INSERT INTO client_secrets (
+    client_id,
+    secret_hash,
+    expires_at,
+    secret_encrypted
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListClientSecrets :many
+SELECT * FROM client_secrets
+WHERE client_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListValidClientSecrets :many
+-- Secrets the client can currently authenticate with, newest first
+SELECT * FROM client_secrets
+WHERE client_id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC;
+
+-- name: ExpireClientSecret :exec
+-- Brings the expiry of a secret forward, a later expiry is never extended
+UPDATE client_secrets
+SET expires_at = LEAST(COALESCE(expires_at, sqlc.arg(expires_at)::timestamptz), sqlc.arg(expires_at)::timestamptz)
+WHERE id = sqlc.arg(id);
+
+-- name: TouchClientSecret :exec
+-- Records that the client authenticated with the secret, at most once a minute
+UPDATE client_secrets
+SET last_used_at = NOW()
+WHERE id = $1
+AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: DeleteClientSecret :execrows
+DELETE FROM client_secrets
+WHERE id = $1 AND client_id = $2;
+
+-- name: DeleteClientSecrets :exec
+DELETE FROM client_secrets
+WHERE client_id = $1;
+
+-- name: DeleteOtherClientSecrets :exec
+-- Removes every secret of the client but the one it keeps during a rotation
+DELETE FROM client_secrets
+WHERE client_id = $1 AND id <> $2;
+
+-- name: ClearEncryptedClientSecrets :exec
+-- Drops the encrypted copies once the client no longer authenticates with client_secret_jwt
+UPDATE client_secrets
+SET secret_encrypted = NULL
+WHERE client_id = $1;
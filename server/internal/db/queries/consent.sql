@@ -0,0 +1,41 @@
+-- name: GetOIDCConsent :one
+SELECT * FROM oidc_consents
+WHERE client_id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: UpsertOIDCConsent :one
+-- Records a grant, adding the scopes to those the user granted the client before
+INSERT INTO oidc_consents (
+    client_id,
+    user_id,
+    scopes
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (client_id, user_id) DO UPDATE
+SET scopes = ARRAY(
+        SELECT DISTINCT scope
+        FROM unnest(oidc_consents.scopes || EXCLUDED.scopes) AS scope
+        ORDER BY scope
+    ),
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListOIDCConsentsByUser :many
+SELECT
+    oidc_consents.id,
+    oidc_consents.client_id,
+    oidc_consents.user_id,
+    oidc_consents.scopes,
+    oidc_consents.created_at,
+    oidc_consents.updated_at,
+    clients.name AS client_name,
+    clients.website AS client_website
+FROM oidc_consents
+JOIN clients ON clients.client_id = oidc_consents.client_id
+WHERE oidc_consents.user_id = $1
+ORDER BY oidc_consents.updated_at DESC;
+
+-- name: DeleteOIDCConsent :execrows
+DELETE FROM oidc_consents
+WHERE client_id = $1 AND user_id = $2;
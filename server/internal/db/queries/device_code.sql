@@ -0,0 +1,59 @@
+-- name: CreateOIDCDeviceCode :one
+INSERT INTO oidc_device_codes (
+    device_code,
+    user_code,
+    client_id,
+    scopes,
+    poll_interval,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetOIDCDeviceCodeByDeviceCode :one
+SELECT * FROM oidc_device_codes
+WHERE device_code = $1
+LIMIT 1;
+
+-- name: GetPendingOIDCDeviceCodeByUserCode :one
+SELECT * FROM oidc_device_codes
+WHERE user_code = $1 AND status = 'pending' AND expires_at > NOW()
+LIMIT 1;
+
+-- name: ApproveOIDCDeviceCode :execrows
+UPDATE oidc_device_codes
+SET status = 'approved',
+    user_id = $2,
+    session_id = $3,
+    auth_time = $4,
+    acr = $5,
+    amr = $6
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: DenyOIDCDeviceCode :execrows
+UPDATE oidc_device_codes
+SET status = 'denied'
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW();
+
+-- name: ConsumeOIDCDeviceCode :one
+-- Marks an approved device code as exchanged for tokens, so it is only exchanged once
+UPDATE oidc_device_codes
+SET status = 'consumed'
+WHERE id = $1 AND status = 'approved' AND expires_at > NOW()
+RETURNING *;
+
+-- name: UpdateOIDCDeviceCodePoll :exec
+UPDATE oidc_device_codes
+SET last_polled_at = NOW(),
+    poll_interval = $2
+WHERE id = $1;
+
+-- name: DeleteExpiredOIDCDeviceCodes :exec
+-- Expired codes are kept for a day, so devices still polling them are told they expired
+DELETE FROM oidc_device_codes
+WHERE expires_at < NOW() - INTERVAL '1 day';
+
+-- name: ReleaseExpiredOIDCDeviceUserCode :execrows
+-- Frees a user code held by an expired request, so user codes are only unique among live requests
+DELETE FROM oidc_device_codes
+WHERE user_code = $1 AND expires_at < NOW();
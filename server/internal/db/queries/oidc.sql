@@ -8,9 +8,13 @@ INSERT INTO oidc_auth_codes (
     scopes,
     code_challenge,
     code_challenge_method,
-    nonce
+    nonce,
+    auth_time,
+    session_id,
+    acr,
+    amr
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 ) RETURNING *;
 
 -- name: GetOIDCAuthCodeByCode :one
@@ -18,6 +22,13 @@ SELECT * FROM oidc_auth_codes
 WHERE code = $1 AND used = false AND expires_at > NOW()
 LIMIT 1;
 
+-- name: ConsumeOIDCAuthCode :one
+-- Only the client the code was issued to can use it up
+UPDATE oidc_auth_codes
+SET used = true
+WHERE code = $1 AND client_id = $2 AND used = false AND expires_at > NOW()
+RETURNING *;
+
 -- name: MarkOIDCAuthCodeAsUsed :exec
 UPDATE oidc_auth_codes
 SET used = true
@@ -29,16 +40,30 @@ INSERT INTO oidc_access_tokens (
     client_id,
     user_id,
     expires_at,
-    scopes
+    scopes,
+    session_id,
+    audience,
+    actor,
+    subject_client_id
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
 -- name: GetOIDCAccessTokenByToken :one
 SELECT * FROM oidc_access_tokens
-WHERE token = $1 AND expires_at > NOW()
+WHERE token = $1 AND revoked = false AND expires_at > NOW()
 LIMIT 1;
 
+-- name: RevokeOIDCAccessToken :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE token = $1;
+
+-- name: RevokeOIDCAccessTokenByID :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE id = $1;
+
 -- name: CreateOIDCRefreshToken :one
 INSERT INTO oidc_refresh_tokens (
     token,
@@ -46,9 +71,13 @@ INSERT INTO oidc_refresh_tokens (
     user_id,
     access_token_id,
     expires_at,
-    scopes
+    scopes,
+    auth_time,
+    session_id,
+    acr,
+    amr
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetOIDCRefreshTokenByToken :one
@@ -56,6 +85,19 @@ SELECT * FROM oidc_refresh_tokens
 WHERE token = $1 AND revoked = false AND expires_at > NOW()
 LIMIT 1;
 
+-- name: GetAnyOIDCRefreshTokenByToken :one
+-- Includes revoked and expired tokens, for reuse detection
+SELECT * FROM oidc_refresh_tokens
+WHERE token = $1
+LIMIT 1;
+
+-- name: RotateOIDCRefreshToken :one
+-- Revokes a refresh token being exchanged; no row is returned if it was already used
+UPDATE oidc_refresh_tokens
+SET revoked = true
+WHERE token = $1 AND revoked = false
+RETURNING *;
+
 -- name: RevokeOIDCRefreshToken :exec
 UPDATE oidc_refresh_tokens
 SET revoked = true
@@ -66,6 +108,32 @@ UPDATE oidc_refresh_tokens
 SET revoked = true
 WHERE client_id = $1 AND user_id = $2;
 
+-- name: RevokeAllClientUserAccessTokens :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE client_id = $1 AND user_id = $2;
+
+-- name: ListFrontchannelLogoutClients :many
+-- Clients with a front-channel logout URI that hold live tokens of a session
+SELECT * FROM clients
+WHERE frontchannel_logout_uri IS NOT NULL
+AND (
+    EXISTS (
+        SELECT 1 FROM oidc_access_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.session_id = $1
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+    OR EXISTS (
+        SELECT 1 FROM oidc_refresh_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.session_id = $1
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+);
+
 -- name: DeleteExpiredOIDCTokens :exec
 DELETE FROM oidc_auth_codes WHERE expires_at < NOW() OR used = true;
 DELETE FROM oidc_access_tokens WHERE expires_at < NOW();
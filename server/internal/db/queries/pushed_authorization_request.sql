@@ -0,0 +1,24 @@
+-- name: CreateOIDCPushedAuthorizationRequest :one
+INSERT INTO oidc_pushed_authorization_requests (
+    request_uri,
+    client_id,
+    parameters,
+    expires_at,
+    from_request_object
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetOIDCPushedAuthorizationRequest :one
+SELECT * FROM oidc_pushed_authorization_requests
+WHERE request_uri = $1 AND expires_at > NOW()
+LIMIT 1;
+
+-- name: DeleteOIDCPushedAuthorizationRequest :exec
+-- Removes a pushed request once a code was issued for it, so it is only used once
+DELETE FROM oidc_pushed_authorization_requests
+WHERE request_uri = $1;
+
+-- name: DeleteExpiredOIDCPushedAuthorizationRequests :exec
+DELETE FROM oidc_pushed_authorization_requests
+WHERE expires_at < NOW();
@@ -0,0 +1,17 @@
+-- name: RecordRequestObjectJTI :execrows
+-- Records the jti of a request object, affecting no row when it was already used.
+-- An expired entry is reused, the request object it belonged to cannot be replayed anymore.
+INSERT INTO request_object_jtis (
+    client_id,
+    jti,
+    expires_at
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (client_id, jti) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+WHERE request_object_jtis.expires_at < NOW();
+
+-- name: DeleteExpiredRequestObjectJTIs :exec
+DELETE FROM request_object_jtis
+WHERE expires_at < NOW();
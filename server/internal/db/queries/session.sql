@@ -3,10 +3,11 @@ INSERT INTO sessions (
     device_name,
     ip_address,
     user_agent,
-    user_id
+    user_id,
+    amr
 )
 VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING id;
 
@@ -143,3 +144,19 @@ WHERE rt.client_id = $1
 AND s.user_id = $2
 AND s.status = 'active'
 AND s.is_logout = false;
+
+-- name: CreateOPSession :exec
+INSERT INTO op_sessions (
+    session_id,
+    identifier,
+    expires_at
+)
+VALUES (
+    $1, $2, $3
+);
+
+-- name: GetOPSessionByIdentifier :one
+SELECT * FROM op_sessions
+WHERE identifier = $1
+AND expires_at > NOW()
+LIMIT 1;
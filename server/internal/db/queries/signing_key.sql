@@ -0,0 +1,80 @@
+-- name: LockSigningKeys :exec
+-- Serializes key rotation between server replicas for the current transaction
+SELECT pg_advisory_xact_lock(hashtext('signing_keys'));
+
+-- name: CreateLegacySigningKey :one
+-- Stores the shared secret of the legacy HS256 algorithm as a retiring key,
+-- so tokens signed with it stay valid until they have expired
+INSERT INTO signing_keys (
+    kid,
+    algorithm,
+    private_key,
+    state,
+    retiring_at
+) VALUES (
+    $1, $2, $3, 'retiring', NOW()
+) RETURNING *;
+
+-- name: CreateSigningKey :one
+INSERT INTO signing_keys (
+    kid,
+    algorithm,
+    private_key,
+    state
+) VALUES (
+    $1, $2, $3, 'pending'
+) RETURNING *;
+
+-- name: GetSigningKeyByKid :one
+SELECT * FROM signing_keys
+WHERE kid = $1;
+
+-- name: GetActiveSigningKey :one
+SELECT * FROM signing_keys
+WHERE state = 'active';
+
+-- name: GetPendingSigningKey :one
+SELECT * FROM signing_keys
+WHERE state = 'pending'
+ORDER BY created_at ASC
+LIMIT 1;
+
+-- name: ListSigningKeys :many
+SELECT * FROM signing_keys
+ORDER BY created_at DESC;
+
+-- name: ListVerificationSigningKeys :many
+-- Keys that are published in the JWKS and accepted when verifying tokens
+SELECT * FROM signing_keys
+WHERE state IN ('pending', 'active', 'retiring')
+ORDER BY created_at DESC;
+
+-- name: ActivateSigningKey :one
+UPDATE signing_keys
+SET state = 'active', activated_at = NOW()
+WHERE kid = $1 AND state = 'pending'
+RETURNING *;
+
+-- name: RetireActiveSigningKey :exec
+UPDATE signing_keys
+SET state = 'retiring', retiring_at = NOW()
+WHERE state = 'active';
+
+-- name: RetireExpiredSigningKeys :many
+-- Retiring keys are kept until every token they signed has expired
+UPDATE signing_keys
+SET state = 'retired', retired_at = NOW()
+WHERE state = 'retiring' AND retiring_at < sqlc.arg(cutoff)::timestamptz
+RETURNING *;
+
+-- name: RetireSigningKey :one
+UPDATE signing_keys
+SET state = 'retired', retired_at = NOW()
+WHERE kid = $1 AND state IN ('pending', 'retiring')
+RETURNING *;
+
+-- name: RetirePendingSigningKeys :exec
+-- Pending keys never signed a token, so those superseded by another key are retired at once
+UPDATE signing_keys
+SET state = 'retired', retired_at = NOW()
+WHERE state = 'pending' AND kid <> $1;
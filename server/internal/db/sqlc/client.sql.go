@@ -15,58 +15,98 @@ import (
 const createClient = `-- name: CreateClient :one
 INSERT INTO clients (
     client_id,
-    client_secret,
     name,
     description,
     website,
-    redirect_uri,
+    redirect_uris,
     is_public,
     oidc_enabled,
     allowed_scopes,
     allowed_grant_types,
-    allowed_response_types
+    allowed_response_types,
+    userinfo_signed_response_alg,
+    post_logout_redirect_uris,
+    backchannel_logout_uri,
+    frontchannel_logout_uri,
+    frontchannel_logout_session_required,
+    is_first_party,
+    allowed_origins,
+    allow_loopback_redirect_ports,
+    token_endpoint_auth_method,
+    jwks,
+    jwks_uri,
+    require_pushed_authorization_requests,
+    require_signed_request_object,
+    request_uris,
+    token_exchange_audiences
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-) RETURNING id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
+) RETURNING id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences
 `
 
 type CreateClientParams struct {
-	ClientID             string         `json:"client_id"`
-	ClientSecret         string         `json:"client_secret"`
-	Name                 string         `json:"name"`
-	Description          sql.NullString `json:"description"`
-	Website              sql.NullString `json:"website"`
-	RedirectUri          string         `json:"redirect_uri"`
-	IsPublic             bool           `json:"is_public"`
-	OidcEnabled          bool           `json:"oidc_enabled"`
-	AllowedScopes        []string       `json:"allowed_scopes"`
-	AllowedGrantTypes    []string       `json:"allowed_grant_types"`
-	AllowedResponseTypes []string       `json:"allowed_response_types"`
+	ClientID                           string         `json:"client_id"`
+	Name                               string         `json:"name"`
+	Description                        sql.NullString `json:"description"`
+	Website                            sql.NullString `json:"website"`
+	RedirectUris                       []string       `json:"redirect_uris"`
+	IsPublic                           bool           `json:"is_public"`
+	OidcEnabled                        bool           `json:"oidc_enabled"`
+	AllowedScopes                      []string       `json:"allowed_scopes"`
+	AllowedGrantTypes                  []string       `json:"allowed_grant_types"`
+	AllowedResponseTypes               []string       `json:"allowed_response_types"`
+	UserinfoSignedResponseAlg          sql.NullString `json:"userinfo_signed_response_alg"`
+	PostLogoutRedirectUris             []string       `json:"post_logout_redirect_uris"`
+	BackchannelLogoutUri               sql.NullString `json:"backchannel_logout_uri"`
+	FrontchannelLogoutUri              sql.NullString `json:"frontchannel_logout_uri"`
+	FrontchannelLogoutSessionRequired  bool           `json:"frontchannel_logout_session_required"`
+	IsFirstParty                       bool           `json:"is_first_party"`
+	AllowedOrigins                     []string       `json:"allowed_origins"`
+	AllowLoopbackRedirectPorts         bool           `json:"allow_loopback_redirect_ports"`
+	TokenEndpointAuthMethod            string         `json:"token_endpoint_auth_method"`
+	Jwks                               sql.NullString `json:"jwks"`
+	JwksUri                            sql.NullString `json:"jwks_uri"`
+	RequirePushedAuthorizationRequests bool           `json:"require_pushed_authorization_requests"`
+	RequireSignedRequestObject         bool           `json:"require_signed_request_object"`
+	RequestUris                        []string       `json:"request_uris"`
+	TokenExchangeAudiences             []string       `json:"token_exchange_audiences"`
 }
 
 func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Client, error) {
 	row := q.db.QueryRowContext(ctx, createClient,
 		arg.ClientID,
-		arg.ClientSecret,
 		arg.Name,
 		arg.Description,
 		arg.Website,
-		arg.RedirectUri,
+		pq.Array(arg.RedirectUris),
 		arg.IsPublic,
 		arg.OidcEnabled,
 		pq.Array(arg.AllowedScopes),
 		pq.Array(arg.AllowedGrantTypes),
 		pq.Array(arg.AllowedResponseTypes),
+		arg.UserinfoSignedResponseAlg,
+		pq.Array(arg.PostLogoutRedirectUris),
+		arg.BackchannelLogoutUri,
+		arg.FrontchannelLogoutUri,
+		arg.FrontchannelLogoutSessionRequired,
+		arg.IsFirstParty,
+		pq.Array(arg.AllowedOrigins),
+		arg.AllowLoopbackRedirectPorts,
+		arg.TokenEndpointAuthMethod,
+		arg.Jwks,
+		arg.JwksUri,
+		arg.RequirePushedAuthorizationRequests,
+		arg.RequireSignedRequestObject,
+		pq.Array(arg.RequestUris),
+		pq.Array(arg.TokenExchangeAudiences),
 	)
 	var i Client
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -74,6 +114,23 @@ func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Cli
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
@@ -89,7 +146,7 @@ func (q *Queries) DeleteClient(ctx context.Context, id int32) error {
 }
 
 const getClientByClientID = `-- name: GetClientByClientID :one
-SELECT id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types FROM clients
+SELECT id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences FROM clients
 WHERE client_id = $1 LIMIT 1
 `
 
@@ -99,11 +156,9 @@ func (q *Queries) GetClientByClientID(ctx context.Context, clientID string) (Cli
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -111,12 +166,29 @@ func (q *Queries) GetClientByClientID(ctx context.Context, clientID string) (Cli
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
 
 const getClientByID = `-- name: GetClientByID :one
-SELECT id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types FROM clients
+SELECT id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences FROM clients
 WHERE id = $1 LIMIT 1
 `
 
@@ -126,11 +198,9 @@ func (q *Queries) GetClientByID(ctx context.Context, id int32) (Client, error) {
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -138,12 +208,45 @@ func (q *Queries) GetClientByID(ctx context.Context, id int32) (Client, error) {
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
 
+const isClientAllowedOrigin = `-- name: IsClientAllowedOrigin :one
+SELECT EXISTS (
+    SELECT 1 FROM clients
+    WHERE allowed_origins @> ARRAY[$1::text]
+    AND oidc_enabled = true
+)
+`
+
+// Whether a web origin is registered by any OIDC client
+func (q *Queries) IsClientAllowedOrigin(ctx context.Context, origin string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isClientAllowedOrigin, origin)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listClients = `-- name: ListClients :many
-SELECT id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types FROM clients
+SELECT id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences FROM clients
 ORDER BY created_at DESC
 `
 
@@ -159,11 +262,9 @@ func (q *Queries) ListClients(ctx context.Context) ([]Client, error) {
 		if err := rows.Scan(
 			&i.ID,
 			&i.ClientID,
-			&i.ClientSecret,
 			&i.Name,
 			&i.Description,
 			&i.Website,
-			&i.RedirectUri,
 			&i.IsPublic,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -171,6 +272,23 @@ func (q *Queries) ListClients(ctx context.Context) ([]Client, error) {
 			pq.Array(&i.AllowedScopes),
 			pq.Array(&i.AllowedGrantTypes),
 			pq.Array(&i.AllowedResponseTypes),
+			&i.UserinfoSignedResponseAlg,
+			pq.Array(&i.PostLogoutRedirectUris),
+			&i.BackchannelLogoutUri,
+			&i.FrontchannelLogoutUri,
+			&i.FrontchannelLogoutSessionRequired,
+			&i.IsFirstParty,
+			pq.Array(&i.RedirectUris),
+			pq.Array(&i.AllowedOrigins),
+			&i.AllowLoopbackRedirectPorts,
+			&i.TokenEndpointAuthMethod,
+			&i.Jwks,
+			&i.JwksUri,
+			&i.RegistrationAccessTokenHash,
+			&i.RequirePushedAuthorizationRequests,
+			&i.RequireSignedRequestObject,
+			pq.Array(&i.RequestUris),
+			pq.Array(&i.TokenExchangeAudiences),
 		); err != nil {
 			return nil, err
 		}
@@ -185,34 +303,81 @@ func (q *Queries) ListClients(ctx context.Context) ([]Client, error) {
 	return items, nil
 }
 
+const setClientRegistrationAccessToken = `-- name: SetClientRegistrationAccessToken :exec
+UPDATE clients
+SET registration_access_token_hash = $2
+WHERE client_id = $1
+`
+
+type SetClientRegistrationAccessTokenParams struct {
+	ClientID                    string         `json:"client_id"`
+	RegistrationAccessTokenHash sql.NullString `json:"registration_access_token_hash"`
+}
+
+// Stores the hash of the token a self-registered client manages its registration with
+func (q *Queries) SetClientRegistrationAccessToken(ctx context.Context, arg SetClientRegistrationAccessTokenParams) error {
+	_, err := q.db.ExecContext(ctx, setClientRegistrationAccessToken, arg.ClientID, arg.RegistrationAccessTokenHash)
+	return err
+}
+
 const updateClient = `-- name: UpdateClient :one
 UPDATE clients
 SET
     name = $2,
     description = $3,
     website = $4,
-    redirect_uri = $5,
+    redirect_uris = $5,
     is_public = $6,
     oidc_enabled = $7,
     allowed_scopes = $8,
     allowed_grant_types = $9,
     allowed_response_types = $10,
+    userinfo_signed_response_alg = $11,
+    post_logout_redirect_uris = $12,
+    backchannel_logout_uri = $13,
+    frontchannel_logout_uri = $14,
+    frontchannel_logout_session_required = $15,
+    is_first_party = $16,
+    allowed_origins = $17,
+    allow_loopback_redirect_ports = $18,
+    token_endpoint_auth_method = $19,
+    jwks = $20,
+    jwks_uri = $21,
+    require_pushed_authorization_requests = $22,
+    require_signed_request_object = $23,
+    request_uris = $24,
+    token_exchange_audiences = $25,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types
+RETURNING id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences
 `
 
 type UpdateClientParams struct {
-	ID                   int32          `json:"id"`
-	Name                 string         `json:"name"`
-	Description          sql.NullString `json:"description"`
-	Website              sql.NullString `json:"website"`
-	RedirectUri          string         `json:"redirect_uri"`
-	IsPublic             bool           `json:"is_public"`
-	OidcEnabled          bool           `json:"oidc_enabled"`
-	AllowedScopes        []string       `json:"allowed_scopes"`
-	AllowedGrantTypes    []string       `json:"allowed_grant_types"`
-	AllowedResponseTypes []string       `json:"allowed_response_types"`
+	ID                                 int32          `json:"id"`
+	Name                               string         `json:"name"`
+	Description                        sql.NullString `json:"description"`
+	Website                            sql.NullString `json:"website"`
+	RedirectUris                       []string       `json:"redirect_uris"`
+	IsPublic                           bool           `json:"is_public"`
+	OidcEnabled                        bool           `json:"oidc_enabled"`
+	AllowedScopes                      []string       `json:"allowed_scopes"`
+	AllowedGrantTypes                  []string       `json:"allowed_grant_types"`
+	AllowedResponseTypes               []string       `json:"allowed_response_types"`
+	UserinfoSignedResponseAlg          sql.NullString `json:"userinfo_signed_response_alg"`
+	PostLogoutRedirectUris             []string       `json:"post_logout_redirect_uris"`
+	BackchannelLogoutUri               sql.NullString `json:"backchannel_logout_uri"`
+	FrontchannelLogoutUri              sql.NullString `json:"frontchannel_logout_uri"`
+	FrontchannelLogoutSessionRequired  bool           `json:"frontchannel_logout_session_required"`
+	IsFirstParty                       bool           `json:"is_first_party"`
+	AllowedOrigins                     []string       `json:"allowed_origins"`
+	AllowLoopbackRedirectPorts         bool           `json:"allow_loopback_redirect_ports"`
+	TokenEndpointAuthMethod            string         `json:"token_endpoint_auth_method"`
+	Jwks                               sql.NullString `json:"jwks"`
+	JwksUri                            sql.NullString `json:"jwks_uri"`
+	RequirePushedAuthorizationRequests bool           `json:"require_pushed_authorization_requests"`
+	RequireSignedRequestObject         bool           `json:"require_signed_request_object"`
+	RequestUris                        []string       `json:"request_uris"`
+	TokenExchangeAudiences             []string       `json:"token_exchange_audiences"`
 }
 
 func (q *Queries) UpdateClient(ctx context.Context, arg UpdateClientParams) (Client, error) {
@@ -221,58 +386,35 @@ func (q *Queries) UpdateClient(ctx context.Context, arg UpdateClientParams) (Cli
 		arg.Name,
 		arg.Description,
 		arg.Website,
-		arg.RedirectUri,
+		pq.Array(arg.RedirectUris),
 		arg.IsPublic,
 		arg.OidcEnabled,
 		pq.Array(arg.AllowedScopes),
 		pq.Array(arg.AllowedGrantTypes),
 		pq.Array(arg.AllowedResponseTypes),
+		arg.UserinfoSignedResponseAlg,
+		pq.Array(arg.PostLogoutRedirectUris),
+		arg.BackchannelLogoutUri,
+		arg.FrontchannelLogoutUri,
+		arg.FrontchannelLogoutSessionRequired,
+		arg.IsFirstParty,
+		pq.Array(arg.AllowedOrigins),
+		arg.AllowLoopbackRedirectPorts,
+		arg.TokenEndpointAuthMethod,
+		arg.Jwks,
+		arg.JwksUri,
+		arg.RequirePushedAuthorizationRequests,
+		arg.RequireSignedRequestObject,
+		pq.Array(arg.RequestUris),
+		pq.Array(arg.TokenExchangeAudiences),
 	)
 	var i Client
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
-		&i.Name,
-		&i.Description,
-		&i.Website,
-		&i.RedirectUri,
-		&i.IsPublic,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.OidcEnabled,
-		pq.Array(&i.AllowedScopes),
-		pq.Array(&i.AllowedGrantTypes),
-		pq.Array(&i.AllowedResponseTypes),
-	)
-	return i, err
-}
-
-const updateClientSecret = `-- name: UpdateClientSecret :one
-UPDATE clients
-SET
-    client_secret = $2,
-    updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types
-`
-
-type UpdateClientSecretParams struct {
-	ID           int32  `json:"id"`
-	ClientSecret string `json:"client_secret"`
-}
-
-func (q *Queries) UpdateClientSecret(ctx context.Context, arg UpdateClientSecretParams) (Client, error) {
-	row := q.db.QueryRowContext(ctx, updateClientSecret, arg.ID, arg.ClientSecret)
-	var i Client
-	err := row.Scan(
-		&i.ID,
-		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -280,6 +422,23 @@ func (q *Queries) UpdateClientSecret(ctx context.Context, arg UpdateClientSecret
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
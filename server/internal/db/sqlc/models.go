@@ -17,31 +17,83 @@ type AccessToken struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+type BackchannelLogoutDelivery struct {
+	ID            int32          `json:"id"`
+	ClientID      string         `json:"client_id"`
+	LogoutUri     string         `json:"logout_uri"`
+	UserID        int32          `json:"user_id"`
+	SessionID     sql.NullInt32  `json:"session_id"`
+	Jti           string         `json:"jti"`
+	Status        string         `json:"status"`
+	Attempts      int32          `json:"attempts"`
+	LastError     sql.NullString `json:"last_error"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	DeliveredAt   sql.NullTime   `json:"delivered_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
 type Client struct {
-	ID                   int32          `json:"id"`
-	ClientID             string         `json:"client_id"`
-	ClientSecret         string         `json:"client_secret"`
-	Name                 string         `json:"name"`
-	Description          sql.NullString `json:"description"`
-	Website              sql.NullString `json:"website"`
-	RedirectUri          string         `json:"redirect_uri"`
-	IsPublic             bool           `json:"is_public"`
-	CreatedAt            time.Time      `json:"created_at"`
-	UpdatedAt            time.Time      `json:"updated_at"`
-	OidcEnabled          bool           `json:"oidc_enabled"`
-	AllowedScopes        []string       `json:"allowed_scopes"`
-	AllowedGrantTypes    []string       `json:"allowed_grant_types"`
-	AllowedResponseTypes []string       `json:"allowed_response_types"`
+	ID                                 int32          `json:"id"`
+	ClientID                           string         `json:"client_id"`
+	Name                               string         `json:"name"`
+	Description                        sql.NullString `json:"description"`
+	Website                            sql.NullString `json:"website"`
+	IsPublic                           bool           `json:"is_public"`
+	CreatedAt                          time.Time      `json:"created_at"`
+	UpdatedAt                          time.Time      `json:"updated_at"`
+	OidcEnabled                        bool           `json:"oidc_enabled"`
+	AllowedScopes                      []string       `json:"allowed_scopes"`
+	AllowedGrantTypes                  []string       `json:"allowed_grant_types"`
+	AllowedResponseTypes               []string       `json:"allowed_response_types"`
+	UserinfoSignedResponseAlg          sql.NullString `json:"userinfo_signed_response_alg"`
+	PostLogoutRedirectUris             []string       `json:"post_logout_redirect_uris"`
+	BackchannelLogoutUri               sql.NullString `json:"backchannel_logout_uri"`
+	FrontchannelLogoutUri              sql.NullString `json:"frontchannel_logout_uri"`
+	FrontchannelLogoutSessionRequired  bool           `json:"frontchannel_logout_session_required"`
+	IsFirstParty                       bool           `json:"is_first_party"`
+	RedirectUris                       []string       `json:"redirect_uris"`
+	AllowedOrigins                     []string       `json:"allowed_origins"`
+	AllowLoopbackRedirectPorts         bool           `json:"allow_loopback_redirect_ports"`
+	TokenEndpointAuthMethod            string         `json:"token_endpoint_auth_method"`
+	Jwks                               sql.NullString `json:"jwks"`
+	JwksUri                            sql.NullString `json:"jwks_uri"`
+	RegistrationAccessTokenHash        sql.NullString `json:"registration_access_token_hash"`
+	RequirePushedAuthorizationRequests bool           `json:"require_pushed_authorization_requests"`
+	RequireSignedRequestObject         bool           `json:"require_signed_request_object"`
+	RequestUris                        []string       `json:"request_uris"`
+	TokenExchangeAudiences             []string       `json:"token_exchange_audiences"`
 }
 
-type OidcAccessToken struct {
-	ID        int32     `json:"id"`
-	Token     string    `json:"token"`
+type ClientAssertionJti struct {
 	ClientID  string    `json:"client_id"`
-	UserID    int32     `json:"user_id"`
+	Jti       string    `json:"jti"`
 	ExpiresAt time.Time `json:"expires_at"`
-	Scopes    []string  `json:"scopes"`
-	CreatedAt time.Time `json:"created_at"`
+}
+
+type ClientSecret struct {
+	ID              int32        `json:"id"`
+	ClientID        string       `json:"client_id"`
+	SecretHash      string       `json:"secret_hash"`
+	CreatedAt       time.Time    `json:"created_at"`
+	LastUsedAt      sql.NullTime `json:"last_used_at"`
+	ExpiresAt       sql.NullTime `json:"expires_at"`
+	SecretEncrypted []byte       `json:"secret_encrypted"`
+}
+
+type OidcAccessToken struct {
+	ID              int32          `json:"id"`
+	Token           string         `json:"token"`
+	ClientID        string         `json:"client_id"`
+	UserID          sql.NullInt32  `json:"user_id"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	Scopes          []string       `json:"scopes"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Revoked         bool           `json:"revoked"`
+	SessionID       sql.NullInt32  `json:"session_id"`
+	Audience        []string       `json:"audience"`
+	Actor           sql.NullString `json:"actor"`
+	SubjectClientID sql.NullString `json:"subject_client_id"`
 }
 
 type OidcAuthCode struct {
@@ -57,18 +109,71 @@ type OidcAuthCode struct {
 	Used                bool           `json:"used"`
 	Nonce               sql.NullString `json:"nonce"`
 	CreatedAt           time.Time      `json:"created_at"`
+	AuthTime            time.Time      `json:"auth_time"`
+	SessionID           sql.NullInt32  `json:"session_id"`
+	Acr                 sql.NullString `json:"acr"`
+	Amr                 []string       `json:"amr"`
+}
+
+type OidcConsent struct {
+	ID        int32     `json:"id"`
+	ClientID  string    `json:"client_id"`
+	UserID    int32     `json:"user_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OidcDeviceCode struct {
+	ID           int32          `json:"id"`
+	DeviceCode   string         `json:"device_code"`
+	UserCode     string         `json:"user_code"`
+	ClientID     string         `json:"client_id"`
+	Scopes       []string       `json:"scopes"`
+	Status       string         `json:"status"`
+	UserID       sql.NullInt32  `json:"user_id"`
+	SessionID    sql.NullInt32  `json:"session_id"`
+	AuthTime     sql.NullTime   `json:"auth_time"`
+	Acr          sql.NullString `json:"acr"`
+	Amr          []string       `json:"amr"`
+	PollInterval int32          `json:"poll_interval"`
+	LastPolledAt sql.NullTime   `json:"last_polled_at"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type OidcPushedAuthorizationRequest struct {
+	ID                int32     `json:"id"`
+	RequestUri        string    `json:"request_uri"`
+	ClientID          string    `json:"client_id"`
+	Parameters        string    `json:"parameters"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	FromRequestObject bool      `json:"from_request_object"`
 }
 
 type OidcRefreshToken struct {
-	ID            int32     `json:"id"`
-	Token         string    `json:"token"`
-	ClientID      string    `json:"client_id"`
-	UserID        int32     `json:"user_id"`
-	AccessTokenID int32     `json:"access_token_id"`
-	ExpiresAt     time.Time `json:"expires_at"`
-	Scopes        []string  `json:"scopes"`
-	Revoked       bool      `json:"revoked"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int32          `json:"id"`
+	Token         string         `json:"token"`
+	ClientID      string         `json:"client_id"`
+	UserID        int32          `json:"user_id"`
+	AccessTokenID int32          `json:"access_token_id"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	Scopes        []string       `json:"scopes"`
+	Revoked       bool           `json:"revoked"`
+	CreatedAt     time.Time      `json:"created_at"`
+	AuthTime      time.Time      `json:"auth_time"`
+	SessionID     sql.NullInt32  `json:"session_id"`
+	Acr           sql.NullString `json:"acr"`
+	Amr           []string       `json:"amr"`
+}
+
+type OpSession struct {
+	ID         int32     `json:"id"`
+	SessionID  int32     `json:"session_id"`
+	Identifier string    `json:"identifier"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type RefreshToken struct {
@@ -80,6 +185,12 @@ type RefreshToken struct {
 	CreatedAt time.Time      `json:"created_at"`
 }
 
+type RequestObjectJti struct {
+	ClientID  string    `json:"client_id"`
+	Jti       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type Session struct {
 	ID             int32          `json:"id"`
 	DeviceName     sql.NullString `json:"device_name"`
@@ -91,6 +202,19 @@ type Session struct {
 	IsLogout       bool           `json:"is_logout"`
 	LastAccessedAt time.Time      `json:"last_accessed_at"`
 	UserID         int32          `json:"user_id"`
+	Amr            []string       `json:"amr"`
+}
+
+type SigningKey struct {
+	ID          int32        `json:"id"`
+	Kid         string       `json:"kid"`
+	Algorithm   string       `json:"algorithm"`
+	PrivateKey  []byte       `json:"private_key"`
+	State       string       `json:"state"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ActivatedAt sql.NullTime `json:"activated_at"`
+	RetiringAt  sql.NullTime `json:"retiring_at"`
+	RetiredAt   sql.NullTime `json:"retired_at"`
 }
 
 type User struct {
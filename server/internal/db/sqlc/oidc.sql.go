@@ -13,24 +13,69 @@ import (
 	"github.com/lib/pq"
 )
 
+const consumeOIDCAuthCode = `-- name: ConsumeOIDCAuthCode :one
+UPDATE oidc_auth_codes
+SET used = true
+WHERE code = $1 AND client_id = $2 AND used = false AND expires_at > NOW()
+RETURNING id, code, client_id, user_id, redirect_uri, expires_at, scopes, code_challenge, code_challenge_method, used, nonce, created_at, auth_time, session_id, acr, amr
+`
+
+type ConsumeOIDCAuthCodeParams struct {
+	Code     string `json:"code"`
+	ClientID string `json:"client_id"`
+}
+
+// Only the client the code was issued to can use it up
+func (q *Queries) ConsumeOIDCAuthCode(ctx context.Context, arg ConsumeOIDCAuthCodeParams) (OidcAuthCode, error) {
+	row := q.db.QueryRowContext(ctx, consumeOIDCAuthCode, arg.Code, arg.ClientID)
+	var i OidcAuthCode
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		&i.ExpiresAt,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.CodeChallengeMethod,
+		&i.Used,
+		&i.Nonce,
+		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
+	)
+	return i, err
+}
+
 const createOIDCAccessToken = `-- name: CreateOIDCAccessToken :one
 INSERT INTO oidc_access_tokens (
     token,
     client_id,
     user_id,
     expires_at,
-    scopes
+    scopes,
+    session_id,
+    audience,
+    actor,
+    subject_client_id
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, token, client_id, user_id, expires_at, scopes, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, token, client_id, user_id, expires_at, scopes, created_at, revoked, session_id, audience, actor, subject_client_id
 `
 
 type CreateOIDCAccessTokenParams struct {
-	Token     string    `json:"token"`
-	ClientID  string    `json:"client_id"`
-	UserID    int32     `json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
-	Scopes    []string  `json:"scopes"`
+	Token           string         `json:"token"`
+	ClientID        string         `json:"client_id"`
+	UserID          sql.NullInt32  `json:"user_id"`
+	ExpiresAt       time.Time      `json:"expires_at"`
+	Scopes          []string       `json:"scopes"`
+	SessionID       sql.NullInt32  `json:"session_id"`
+	Audience        []string       `json:"audience"`
+	Actor           sql.NullString `json:"actor"`
+	SubjectClientID sql.NullString `json:"subject_client_id"`
 }
 
 func (q *Queries) CreateOIDCAccessToken(ctx context.Context, arg CreateOIDCAccessTokenParams) (OidcAccessToken, error) {
@@ -40,6 +85,10 @@ func (q *Queries) CreateOIDCAccessToken(ctx context.Context, arg CreateOIDCAcces
 		arg.UserID,
 		arg.ExpiresAt,
 		pq.Array(arg.Scopes),
+		arg.SessionID,
+		pq.Array(arg.Audience),
+		arg.Actor,
+		arg.SubjectClientID,
 	)
 	var i OidcAccessToken
 	err := row.Scan(
@@ -50,6 +99,11 @@ func (q *Queries) CreateOIDCAccessToken(ctx context.Context, arg CreateOIDCAcces
 		&i.ExpiresAt,
 		pq.Array(&i.Scopes),
 		&i.CreatedAt,
+		&i.Revoked,
+		&i.SessionID,
+		pq.Array(&i.Audience),
+		&i.Actor,
+		&i.SubjectClientID,
 	)
 	return i, err
 }
@@ -64,10 +118,14 @@ INSERT INTO oidc_auth_codes (
     scopes,
     code_challenge,
     code_challenge_method,
-    nonce
+    nonce,
+    auth_time,
+    session_id,
+    acr,
+    amr
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9
-) RETURNING id, code, client_id, user_id, redirect_uri, expires_at, scopes, code_challenge, code_challenge_method, used, nonce, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING id, code, client_id, user_id, redirect_uri, expires_at, scopes, code_challenge, code_challenge_method, used, nonce, created_at, auth_time, session_id, acr, amr
 `
 
 type CreateOIDCAuthCodeParams struct {
@@ -80,6 +138,10 @@ type CreateOIDCAuthCodeParams struct {
 	CodeChallenge       sql.NullString `json:"code_challenge"`
 	CodeChallengeMethod sql.NullString `json:"code_challenge_method"`
 	Nonce               sql.NullString `json:"nonce"`
+	AuthTime            time.Time      `json:"auth_time"`
+	SessionID           sql.NullInt32  `json:"session_id"`
+	Acr                 sql.NullString `json:"acr"`
+	Amr                 []string       `json:"amr"`
 }
 
 func (q *Queries) CreateOIDCAuthCode(ctx context.Context, arg CreateOIDCAuthCodeParams) (OidcAuthCode, error) {
@@ -93,6 +155,10 @@ func (q *Queries) CreateOIDCAuthCode(ctx context.Context, arg CreateOIDCAuthCode
 		arg.CodeChallenge,
 		arg.CodeChallengeMethod,
 		arg.Nonce,
+		arg.AuthTime,
+		arg.SessionID,
+		arg.Acr,
+		pq.Array(arg.Amr),
 	)
 	var i OidcAuthCode
 	err := row.Scan(
@@ -108,6 +174,10 @@ func (q *Queries) CreateOIDCAuthCode(ctx context.Context, arg CreateOIDCAuthCode
 		&i.Used,
 		&i.Nonce,
 		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
@@ -119,19 +189,27 @@ INSERT INTO oidc_refresh_tokens (
     user_id,
     access_token_id,
     expires_at,
-    scopes
+    scopes,
+    auth_time,
+    session_id,
+    acr,
+    amr
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
-) RETURNING id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at, auth_time, session_id, acr, amr
 `
 
 type CreateOIDCRefreshTokenParams struct {
-	Token         string    `json:"token"`
-	ClientID      string    `json:"client_id"`
-	UserID        int32     `json:"user_id"`
-	AccessTokenID int32     `json:"access_token_id"`
-	ExpiresAt     time.Time `json:"expires_at"`
-	Scopes        []string  `json:"scopes"`
+	Token         string         `json:"token"`
+	ClientID      string         `json:"client_id"`
+	UserID        int32          `json:"user_id"`
+	AccessTokenID int32          `json:"access_token_id"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	Scopes        []string       `json:"scopes"`
+	AuthTime      time.Time      `json:"auth_time"`
+	SessionID     sql.NullInt32  `json:"session_id"`
+	Acr           sql.NullString `json:"acr"`
+	Amr           []string       `json:"amr"`
 }
 
 func (q *Queries) CreateOIDCRefreshToken(ctx context.Context, arg CreateOIDCRefreshTokenParams) (OidcRefreshToken, error) {
@@ -142,6 +220,10 @@ func (q *Queries) CreateOIDCRefreshToken(ctx context.Context, arg CreateOIDCRefr
 		arg.AccessTokenID,
 		arg.ExpiresAt,
 		pq.Array(arg.Scopes),
+		arg.AuthTime,
+		arg.SessionID,
+		arg.Acr,
+		pq.Array(arg.Amr),
 	)
 	var i OidcRefreshToken
 	err := row.Scan(
@@ -154,6 +236,10 @@ func (q *Queries) CreateOIDCRefreshToken(ctx context.Context, arg CreateOIDCRefr
 		pq.Array(&i.Scopes),
 		&i.Revoked,
 		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
@@ -167,8 +253,36 @@ func (q *Queries) DeleteExpiredOIDCTokens(ctx context.Context) error {
 	return err
 }
 
+const getAnyOIDCRefreshTokenByToken = `-- name: GetAnyOIDCRefreshTokenByToken :one
+SELECT id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at, auth_time, session_id, acr, amr FROM oidc_refresh_tokens
+WHERE token = $1
+LIMIT 1
+`
+
+// Includes revoked and expired tokens, for reuse detection
+func (q *Queries) GetAnyOIDCRefreshTokenByToken(ctx context.Context, token string) (OidcRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getAnyOIDCRefreshTokenByToken, token)
+	var i OidcRefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.ClientID,
+		&i.UserID,
+		&i.AccessTokenID,
+		&i.ExpiresAt,
+		pq.Array(&i.Scopes),
+		&i.Revoked,
+		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
+	)
+	return i, err
+}
+
 const getClientWithOIDCSettings = `-- name: GetClientWithOIDCSettings :one
-SELECT id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types FROM clients
+SELECT id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences FROM clients
 WHERE client_id = $1 AND oidc_enabled = true
 LIMIT 1
 `
@@ -179,11 +293,9 @@ func (q *Queries) GetClientWithOIDCSettings(ctx context.Context, clientID string
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -191,13 +303,30 @@ func (q *Queries) GetClientWithOIDCSettings(ctx context.Context, clientID string
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
 
 const getOIDCAccessTokenByToken = `-- name: GetOIDCAccessTokenByToken :one
-SELECT id, token, client_id, user_id, expires_at, scopes, created_at FROM oidc_access_tokens
-WHERE token = $1 AND expires_at > NOW()
+SELECT id, token, client_id, user_id, expires_at, scopes, created_at, revoked, session_id, audience, actor, subject_client_id FROM oidc_access_tokens
+WHERE token = $1 AND revoked = false AND expires_at > NOW()
 LIMIT 1
 `
 
@@ -212,12 +341,17 @@ func (q *Queries) GetOIDCAccessTokenByToken(ctx context.Context, token string) (
 		&i.ExpiresAt,
 		pq.Array(&i.Scopes),
 		&i.CreatedAt,
+		&i.Revoked,
+		&i.SessionID,
+		pq.Array(&i.Audience),
+		&i.Actor,
+		&i.SubjectClientID,
 	)
 	return i, err
 }
 
 const getOIDCAuthCodeByCode = `-- name: GetOIDCAuthCodeByCode :one
-SELECT id, code, client_id, user_id, redirect_uri, expires_at, scopes, code_challenge, code_challenge_method, used, nonce, created_at FROM oidc_auth_codes
+SELECT id, code, client_id, user_id, redirect_uri, expires_at, scopes, code_challenge, code_challenge_method, used, nonce, created_at, auth_time, session_id, acr, amr FROM oidc_auth_codes
 WHERE code = $1 AND used = false AND expires_at > NOW()
 LIMIT 1
 `
@@ -238,12 +372,16 @@ func (q *Queries) GetOIDCAuthCodeByCode(ctx context.Context, code string) (OidcA
 		&i.Used,
 		&i.Nonce,
 		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
 
 const getOIDCRefreshTokenByToken = `-- name: GetOIDCRefreshTokenByToken :one
-SELECT id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at FROM oidc_refresh_tokens
+SELECT id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at, auth_time, session_id, acr, amr FROM oidc_refresh_tokens
 WHERE token = $1 AND revoked = false AND expires_at > NOW()
 LIMIT 1
 `
@@ -261,10 +399,89 @@ func (q *Queries) GetOIDCRefreshTokenByToken(ctx context.Context, token string)
 		pq.Array(&i.Scopes),
 		&i.Revoked,
 		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
 
+const listFrontchannelLogoutClients = `-- name: ListFrontchannelLogoutClients :many
+SELECT id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences FROM clients
+WHERE frontchannel_logout_uri IS NOT NULL
+AND (
+    EXISTS (
+        SELECT 1 FROM oidc_access_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.session_id = $1
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+    OR EXISTS (
+        SELECT 1 FROM oidc_refresh_tokens t
+        WHERE t.client_id = clients.client_id
+        AND t.session_id = $1
+        AND t.revoked = false
+        AND t.expires_at > NOW()
+    )
+)
+`
+
+// Clients with a front-channel logout URI that hold live tokens of a session
+func (q *Queries) ListFrontchannelLogoutClients(ctx context.Context, sessionID sql.NullInt32) ([]Client, error) {
+	rows, err := q.db.QueryContext(ctx, listFrontchannelLogoutClients, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Client{}
+	for rows.Next() {
+		var i Client
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.Name,
+			&i.Description,
+			&i.Website,
+			&i.IsPublic,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OidcEnabled,
+			pq.Array(&i.AllowedScopes),
+			pq.Array(&i.AllowedGrantTypes),
+			pq.Array(&i.AllowedResponseTypes),
+			&i.UserinfoSignedResponseAlg,
+			pq.Array(&i.PostLogoutRedirectUris),
+			&i.BackchannelLogoutUri,
+			&i.FrontchannelLogoutUri,
+			&i.FrontchannelLogoutSessionRequired,
+			&i.IsFirstParty,
+			pq.Array(&i.RedirectUris),
+			pq.Array(&i.AllowedOrigins),
+			&i.AllowLoopbackRedirectPorts,
+			&i.TokenEndpointAuthMethod,
+			&i.Jwks,
+			&i.JwksUri,
+			&i.RegistrationAccessTokenHash,
+			&i.RequirePushedAuthorizationRequests,
+			&i.RequireSignedRequestObject,
+			pq.Array(&i.RequestUris),
+			pq.Array(&i.TokenExchangeAudiences),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markOIDCAuthCodeAsUsed = `-- name: MarkOIDCAuthCodeAsUsed :exec
 UPDATE oidc_auth_codes
 SET used = true
@@ -276,6 +493,22 @@ func (q *Queries) MarkOIDCAuthCodeAsUsed(ctx context.Context, code string) error
 	return err
 }
 
+const revokeAllClientUserAccessTokens = `-- name: RevokeAllClientUserAccessTokens :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE client_id = $1 AND user_id = $2
+`
+
+type RevokeAllClientUserAccessTokensParams struct {
+	ClientID string        `json:"client_id"`
+	UserID   sql.NullInt32 `json:"user_id"`
+}
+
+func (q *Queries) RevokeAllClientUserAccessTokens(ctx context.Context, arg RevokeAllClientUserAccessTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeAllClientUserAccessTokens, arg.ClientID, arg.UserID)
+	return err
+}
+
 const revokeAllClientUserRefreshTokens = `-- name: RevokeAllClientUserRefreshTokens :exec
 UPDATE oidc_refresh_tokens
 SET revoked = true
@@ -292,6 +525,28 @@ func (q *Queries) RevokeAllClientUserRefreshTokens(ctx context.Context, arg Revo
 	return err
 }
 
+const revokeOIDCAccessToken = `-- name: RevokeOIDCAccessToken :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE token = $1
+`
+
+func (q *Queries) RevokeOIDCAccessToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, revokeOIDCAccessToken, token)
+	return err
+}
+
+const revokeOIDCAccessTokenByID = `-- name: RevokeOIDCAccessTokenByID :exec
+UPDATE oidc_access_tokens
+SET revoked = true
+WHERE id = $1
+`
+
+func (q *Queries) RevokeOIDCAccessTokenByID(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, revokeOIDCAccessTokenByID, id)
+	return err
+}
+
 const revokeOIDCRefreshToken = `-- name: RevokeOIDCRefreshToken :exec
 UPDATE oidc_refresh_tokens
 SET revoked = true
@@ -303,6 +558,35 @@ func (q *Queries) RevokeOIDCRefreshToken(ctx context.Context, token string) erro
 	return err
 }
 
+const rotateOIDCRefreshToken = `-- name: RotateOIDCRefreshToken :one
+UPDATE oidc_refresh_tokens
+SET revoked = true
+WHERE token = $1 AND revoked = false
+RETURNING id, token, client_id, user_id, access_token_id, expires_at, scopes, revoked, created_at, auth_time, session_id, acr, amr
+`
+
+// Revokes a refresh token being exchanged; no row is returned if it was already used
+func (q *Queries) RotateOIDCRefreshToken(ctx context.Context, token string) (OidcRefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateOIDCRefreshToken, token)
+	var i OidcRefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.ClientID,
+		&i.UserID,
+		&i.AccessTokenID,
+		&i.ExpiresAt,
+		pq.Array(&i.Scopes),
+		&i.Revoked,
+		&i.CreatedAt,
+		&i.AuthTime,
+		&i.SessionID,
+		&i.Acr,
+		pq.Array(&i.Amr),
+	)
+	return i, err
+}
+
 const updateClientOIDCSettings = `-- name: UpdateClientOIDCSettings :one
 UPDATE clients
 SET 
@@ -312,7 +596,7 @@ SET
     allowed_response_types = $4,
     updated_at = NOW()
 WHERE client_id = $5
-RETURNING id, client_id, client_secret, name, description, website, redirect_uri, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types
+RETURNING id, client_id, name, description, website, is_public, created_at, updated_at, oidc_enabled, allowed_scopes, allowed_grant_types, allowed_response_types, userinfo_signed_response_alg, post_logout_redirect_uris, backchannel_logout_uri, frontchannel_logout_uri, frontchannel_logout_session_required, is_first_party, redirect_uris, allowed_origins, allow_loopback_redirect_ports, token_endpoint_auth_method, jwks, jwks_uri, registration_access_token_hash, require_pushed_authorization_requests, require_signed_request_object, request_uris, token_exchange_audiences
 `
 
 type UpdateClientOIDCSettingsParams struct {
@@ -335,11 +619,9 @@ func (q *Queries) UpdateClientOIDCSettings(ctx context.Context, arg UpdateClient
 	err := row.Scan(
 		&i.ID,
 		&i.ClientID,
-		&i.ClientSecret,
 		&i.Name,
 		&i.Description,
 		&i.Website,
-		&i.RedirectUri,
 		&i.IsPublic,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -347,6 +629,23 @@ func (q *Queries) UpdateClientOIDCSettings(ctx context.Context, arg UpdateClient
 		pq.Array(&i.AllowedScopes),
 		pq.Array(&i.AllowedGrantTypes),
 		pq.Array(&i.AllowedResponseTypes),
+		&i.UserinfoSignedResponseAlg,
+		pq.Array(&i.PostLogoutRedirectUris),
+		&i.BackchannelLogoutUri,
+		&i.FrontchannelLogoutUri,
+		&i.FrontchannelLogoutSessionRequired,
+		&i.IsFirstParty,
+		pq.Array(&i.RedirectUris),
+		pq.Array(&i.AllowedOrigins),
+		&i.AllowLoopbackRedirectPorts,
+		&i.TokenEndpointAuthMethod,
+		&i.Jwks,
+		&i.JwksUri,
+		&i.RegistrationAccessTokenHash,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		pq.Array(&i.RequestUris),
+		pq.Array(&i.TokenExchangeAudiences),
 	)
 	return i, err
 }
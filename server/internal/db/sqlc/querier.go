@@ -7,31 +7,76 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	ActivateSigningKey(ctx context.Context, kid string) (SigningKey, error)
+	ApproveOIDCDeviceCode(ctx context.Context, arg ApproveOIDCDeviceCodeParams) (int64, error)
+	// Leases the pending deliveries that are due, so each is sent by one replica only
+	ClaimDueBackchannelLogoutDeliveries(ctx context.Context, arg ClaimDueBackchannelLogoutDeliveriesParams) ([]BackchannelLogoutDelivery, error)
+	// Drops the encrypted copies once the client no longer authenticates with client_secret_jwt
+	ClearEncryptedClientSecrets(ctx context.Context, clientID string) error
+	// Only the client the code was issued to can use it up
+	ConsumeOIDCAuthCode(ctx context.Context, arg ConsumeOIDCAuthCodeParams) (OidcAuthCode, error)
+	// Marks an approved device code as exchanged for tokens, so it is only exchanged once
+	ConsumeOIDCDeviceCode(ctx context.Context, id int32) (OidcDeviceCode, error)
 	CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) (int32, error)
+	CreateBackchannelLogoutDelivery(ctx context.Context, arg CreateBackchannelLogoutDeliveryParams) (BackchannelLogoutDelivery, error)
 	CreateClient(ctx context.Context, arg CreateClientParams) (Client, error)
+	CreateClientSecret(ctx context.Context, arg CreateClientSecretParams) (ClientSecret, error)
+	// Stores the shared secret of the legacy HS256 algorithm as a retiring key,
+	// so tokens signed with it stay valid until they have expired
+	CreateLegacySigningKey(ctx context.Context, arg CreateLegacySigningKeyParams) (SigningKey, error)
 	CreateOIDCAccessToken(ctx context.Context, arg CreateOIDCAccessTokenParams) (OidcAccessToken, error)
 	CreateOIDCAuthCode(ctx context.Context, arg CreateOIDCAuthCodeParams) (OidcAuthCode, error)
+	CreateOIDCDeviceCode(ctx context.Context, arg CreateOIDCDeviceCodeParams) (OidcDeviceCode, error)
+	CreateOIDCPushedAuthorizationRequest(ctx context.Context, arg CreateOIDCPushedAuthorizationRequestParams) (OidcPushedAuthorizationRequest, error)
 	CreateOIDCRefreshToken(ctx context.Context, arg CreateOIDCRefreshTokenParams) (OidcRefreshToken, error)
+	CreateOPSession(ctx context.Context, arg CreateOPSessionParams) error
 	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (int32, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (int32, error)
+	CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) (SigningKey, error)
 	DeleteClient(ctx context.Context, id int32) error
+	DeleteClientSecret(ctx context.Context, arg DeleteClientSecretParams) (int64, error)
+	DeleteClientSecrets(ctx context.Context, clientID string) error
+	DeleteExpiredClientAssertionJTIs(ctx context.Context) error
+	// Expired codes are kept for a day, so devices still polling them are told they expired
+	DeleteExpiredOIDCDeviceCodes(ctx context.Context) error
+	DeleteExpiredOIDCPushedAuthorizationRequests(ctx context.Context) error
 	DeleteExpiredOIDCTokens(ctx context.Context) error
+	DeleteExpiredRequestObjectJTIs(ctx context.Context) error
+	DeleteOIDCConsent(ctx context.Context, arg DeleteOIDCConsentParams) (int64, error)
+	// Removes a pushed request once a code was issued for it, so it is only used once
+	DeleteOIDCPushedAuthorizationRequest(ctx context.Context, requestUri string) error
+	// Removes every secret of the client but the one it keeps during a rotation
+	DeleteOtherClientSecrets(ctx context.Context, arg DeleteOtherClientSecretsParams) error
+	DenyOIDCDeviceCode(ctx context.Context, id int32) (int64, error)
+	// Brings the expiry of a secret forward, a later expiry is never extended
+	ExpireClientSecret(ctx context.Context, arg ExpireClientSecretParams) error
 	GetAccessTokenByRefreshTokenID(ctx context.Context, refreshTokenID int32) (AccessToken, error)
 	GetAccessTokenByToken(ctx context.Context, token string) (GetAccessTokenByTokenRow, error)
+	GetActiveSigningKey(ctx context.Context) (SigningKey, error)
+	// Includes revoked and expired tokens, for reuse detection
+	GetAnyOIDCRefreshTokenByToken(ctx context.Context, token string) (OidcRefreshToken, error)
 	GetClientByClientID(ctx context.Context, clientID string) (Client, error)
 	GetClientByID(ctx context.Context, id int32) (Client, error)
 	GetClientWithOIDCSettings(ctx context.Context, clientID string) (Client, error)
 	GetOIDCAccessTokenByToken(ctx context.Context, token string) (OidcAccessToken, error)
 	GetOIDCAuthCodeByCode(ctx context.Context, code string) (OidcAuthCode, error)
+	GetOIDCConsent(ctx context.Context, arg GetOIDCConsentParams) (OidcConsent, error)
+	GetOIDCDeviceCodeByDeviceCode(ctx context.Context, deviceCode string) (OidcDeviceCode, error)
+	GetOIDCPushedAuthorizationRequest(ctx context.Context, requestUri string) (OidcPushedAuthorizationRequest, error)
 	GetOIDCRefreshTokenByToken(ctx context.Context, token string) (OidcRefreshToken, error)
+	GetOPSessionByIdentifier(ctx context.Context, identifier string) (OpSession, error)
+	GetPendingOIDCDeviceCodeByUserCode(ctx context.Context, userCode string) (OidcDeviceCode, error)
+	GetPendingSigningKey(ctx context.Context) (SigningKey, error)
 	GetRefreshTokenByClientID(ctx context.Context, arg GetRefreshTokenByClientIDParams) ([]RefreshToken, error)
 	GetRefreshTokenByToken(ctx context.Context, token string) (GetRefreshTokenByTokenRow, error)
 	GetRefreshTokensBySessionID(ctx context.Context, sessionID int32) ([]RefreshToken, error)
 	GetSessionByID(ctx context.Context, id int32) (Session, error)
 	GetSessionByRefreshTokenID(ctx context.Context, id int32) (Session, error)
+	GetSigningKeyByKid(ctx context.Context, kid string) (SigningKey, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserById(ctx context.Context, id int32) (User, error)
 	GetUserByIdentifier(ctx context.Context, username string) (User, error)
@@ -39,19 +84,65 @@ type Querier interface {
 	GetUserByUsername(ctx context.Context, username string) (User, error)
 	GetUserSessions(ctx context.Context, userID int32) ([]GetUserSessionsRow, error)
 	InvalidateRefreshToken(ctx context.Context, id int32) error
+	// Whether a web origin is registered by any OIDC client
+	IsClientAllowedOrigin(ctx context.Context, origin string) (bool, error)
+	// Clients with a back-channel logout URI that hold live tokens of the user,
+	// limited to the tokens of one session when session_id is set
+	ListBackchannelLogoutClients(ctx context.Context, arg ListBackchannelLogoutClientsParams) ([]Client, error)
+	ListBackchannelLogoutDeliveriesByClient(ctx context.Context, arg ListBackchannelLogoutDeliveriesByClientParams) ([]BackchannelLogoutDelivery, error)
+	ListClientSecrets(ctx context.Context, clientID string) ([]ClientSecret, error)
 	ListClients(ctx context.Context) ([]Client, error)
+	// Clients with a front-channel logout URI that hold live tokens of a session
+	ListFrontchannelLogoutClients(ctx context.Context, sessionID sql.NullInt32) ([]Client, error)
+	ListOIDCConsentsByUser(ctx context.Context, userID int32) ([]ListOIDCConsentsByUserRow, error)
+	ListSigningKeys(ctx context.Context) ([]SigningKey, error)
+	// Secrets the client can currently authenticate with, newest first
+	ListValidClientSecrets(ctx context.Context, clientID string) ([]ClientSecret, error)
+	// Keys that are published in the JWKS and accepted when verifying tokens
+	ListVerificationSigningKeys(ctx context.Context) ([]SigningKey, error)
+	// Serializes secret rotations of a client for the current transaction
+	LockClientSecrets(ctx context.Context, clientID string) error
+	// Serializes key rotation between server replicas for the current transaction
+	LockSigningKeys(ctx context.Context) error
 	LogoutSession(ctx context.Context, id int32) error
+	MarkBackchannelLogoutDelivered(ctx context.Context, id int32) error
 	MarkOIDCAuthCodeAsUsed(ctx context.Context, code string) error
+	RecordBackchannelLogoutFailure(ctx context.Context, arg RecordBackchannelLogoutFailureParams) error
+	// Records the jti of an assertion, affecting no row when it was already used.
+	// An expired entry is reused, the assertion it belonged to cannot be replayed anymore.
+	RecordClientAssertionJTI(ctx context.Context, arg RecordClientAssertionJTIParams) (int64, error)
+	// Records the jti of a request object, affecting no row when it was already used.
+	// An expired entry is reused, the request object it belonged to cannot be replayed anymore.
+	RecordRequestObjectJTI(ctx context.Context, arg RecordRequestObjectJTIParams) (int64, error)
 	RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error)
+	// Frees a user code held by an expired request, so user codes are only unique among live requests
+	ReleaseExpiredOIDCDeviceUserCode(ctx context.Context, userCode string) (int64, error)
+	RetireActiveSigningKey(ctx context.Context) error
+	// Retiring keys are kept until every token they signed has expired
+	RetireExpiredSigningKeys(ctx context.Context, cutoff time.Time) ([]SigningKey, error)
+	// Pending keys never signed a token, so those superseded by another key are retired at once
+	RetirePendingSigningKeys(ctx context.Context, kid string) error
+	RetireSigningKey(ctx context.Context, kid string) (SigningKey, error)
+	RevokeAllClientUserAccessTokens(ctx context.Context, arg RevokeAllClientUserAccessTokensParams) error
 	RevokeAllClientUserRefreshTokens(ctx context.Context, arg RevokeAllClientUserRefreshTokensParams) error
 	RevokeAllUserSessions(ctx context.Context, userID int32) error
+	RevokeOIDCAccessToken(ctx context.Context, token string) error
+	RevokeOIDCAccessTokenByID(ctx context.Context, id int32) error
 	RevokeOIDCRefreshToken(ctx context.Context, token string) error
 	RevokeSession(ctx context.Context, id int32) error
+	// Revokes a refresh token being exchanged; no row is returned if it was already used
+	RotateOIDCRefreshToken(ctx context.Context, token string) (OidcRefreshToken, error)
+	// Stores the hash of the token a self-registered client manages its registration with
+	SetClientRegistrationAccessToken(ctx context.Context, arg SetClientRegistrationAccessTokenParams) error
+	// Records that the client authenticated with the secret, at most once a minute
+	TouchClientSecret(ctx context.Context, id int32) error
 	UpdateAccessToken(ctx context.Context, arg UpdateAccessTokenParams) error
 	UpdateClient(ctx context.Context, arg UpdateClientParams) (Client, error)
 	UpdateClientOIDCSettings(ctx context.Context, arg UpdateClientOIDCSettingsParams) (Client, error)
-	UpdateClientSecret(ctx context.Context, arg UpdateClientSecretParams) (Client, error)
 	UpdateLastAccessed(ctx context.Context, id int32) error
+	UpdateOIDCDeviceCodePoll(ctx context.Context, arg UpdateOIDCDeviceCodePollParams) error
+	// Records a grant, adding the scopes to those the user granted the client before
+	UpsertOIDCConsent(ctx context.Context, arg UpsertOIDCConsentParams) (OidcConsent, error)
 }
 
 var _ Querier = (*Queries)(nil)
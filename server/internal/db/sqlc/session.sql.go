@@ -9,6 +9,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const createAccessToken = `-- name: CreateAccessToken :one
@@ -36,6 +38,28 @@ func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenPa
 	return id, err
 }
 
+const createOPSession = `-- name: CreateOPSession :exec
+INSERT INTO op_sessions (
+    session_id,
+    identifier,
+    expires_at
+)
+VALUES (
+    $1, $2, $3
+)
+`
+
+type CreateOPSessionParams struct {
+	SessionID  int32     `json:"session_id"`
+	Identifier string    `json:"identifier"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateOPSession(ctx context.Context, arg CreateOPSessionParams) error {
+	_, err := q.db.ExecContext(ctx, createOPSession, arg.SessionID, arg.Identifier, arg.ExpiresAt)
+	return err
+}
+
 const createRefreshToken = `-- name: CreateRefreshToken :one
 INSERT INTO refresh_tokens (
     session_id,
@@ -73,10 +97,11 @@ INSERT INTO sessions (
     device_name,
     ip_address,
     user_agent,
-    user_id
+    user_id,
+    amr
 )
 VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING id
 `
@@ -86,6 +111,7 @@ type CreateSessionParams struct {
 	IpAddress  sql.NullString `json:"ip_address"`
 	UserAgent  sql.NullString `json:"user_agent"`
 	UserID     int32          `json:"user_id"`
+	Amr        []string       `json:"amr"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (int32, error) {
@@ -94,6 +120,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (i
 		arg.IpAddress,
 		arg.UserAgent,
 		arg.UserID,
+		pq.Array(arg.Amr),
 	)
 	var id int32
 	err := row.Scan(&id)
@@ -159,6 +186,26 @@ func (q *Queries) GetAccessTokenByToken(ctx context.Context, token string) (GetA
 	return i, err
 }
 
+const getOPSessionByIdentifier = `-- name: GetOPSessionByIdentifier :one
+SELECT id, session_id, identifier, expires_at, created_at FROM op_sessions
+WHERE identifier = $1
+AND expires_at > NOW()
+LIMIT 1
+`
+
+func (q *Queries) GetOPSessionByIdentifier(ctx context.Context, identifier string) (OpSession, error) {
+	row := q.db.QueryRowContext(ctx, getOPSessionByIdentifier, identifier)
+	var i OpSession
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Identifier,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getRefreshTokenByClientID = `-- name: GetRefreshTokenByClientID :many
 SELECT rt.id, rt.session_id, rt.token, rt.client_id, rt.expires_at, rt.created_at 
 FROM refresh_tokens rt
@@ -279,7 +326,7 @@ func (q *Queries) GetRefreshTokensBySessionID(ctx context.Context, sessionID int
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, device_name, ip_address, user_agent, status, created_at, updated_at, is_logout, last_accessed_at, user_id FROM sessions
+SELECT id, device_name, ip_address, user_agent, status, created_at, updated_at, is_logout, last_accessed_at, user_id, amr FROM sessions
 WHERE id = $1
 AND status = 'active'
 AND is_logout = false
@@ -300,12 +347,13 @@ func (q *Queries) GetSessionByID(ctx context.Context, id int32) (Session, error)
 		&i.IsLogout,
 		&i.LastAccessedAt,
 		&i.UserID,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
 
 const getSessionByRefreshTokenID = `-- name: GetSessionByRefreshTokenID :one
-SELECT s.id, s.device_name, s.ip_address, s.user_agent, s.status, s.created_at, s.updated_at, s.is_logout, s.last_accessed_at, s.user_id
+SELECT s.id, s.device_name, s.ip_address, s.user_agent, s.status, s.created_at, s.updated_at, s.is_logout, s.last_accessed_at, s.user_id, s.amr
 FROM sessions s
 JOIN refresh_tokens rt ON s.id = rt.session_id
 WHERE rt.id = $1
@@ -328,12 +376,13 @@ func (q *Queries) GetSessionByRefreshTokenID(ctx context.Context, id int32) (Ses
 		&i.IsLogout,
 		&i.LastAccessedAt,
 		&i.UserID,
+		pq.Array(&i.Amr),
 	)
 	return i, err
 }
 
 const getUserSessions = `-- name: GetUserSessions :many
-SELECT s.id, s.device_name, s.ip_address, s.user_agent, s.status, s.created_at, s.updated_at, s.is_logout, s.last_accessed_at, s.user_id, COUNT(rt.id) as token_count 
+SELECT s.id, s.device_name, s.ip_address, s.user_agent, s.status, s.created_at, s.updated_at, s.is_logout, s.last_accessed_at, s.user_id, s.amr, COUNT(rt.id) as token_count 
 FROM sessions s
 LEFT JOIN refresh_tokens rt ON s.id = rt.session_id
 WHERE s.user_id = $1
@@ -354,6 +403,7 @@ type GetUserSessionsRow struct {
 	IsLogout       bool           `json:"is_logout"`
 	LastAccessedAt time.Time      `json:"last_accessed_at"`
 	UserID         int32          `json:"user_id"`
+	Amr            []string       `json:"amr"`
 	TokenCount     int64          `json:"token_count"`
 }
 
@@ -377,6 +427,7 @@ func (q *Queries) GetUserSessions(ctx context.Context, userID int32) ([]GetUserS
 			&i.IsLogout,
 			&i.LastAccessedAt,
 			&i.UserID,
+			pq.Array(&i.Amr),
 			&i.TokenCount,
 		); err != nil {
 			return nil, err
@@ -82,6 +82,8 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		DeviceName: sql.NullString{String: deviceName, Valid: deviceName != ""},
 		IpAddress:  sql.NullString{String: ipAddress, Valid: ipAddress != ""},
 		UserAgent:  sql.NullString{String: userAgent, Valid: userAgent != ""},
+		// Sign-in only checks the password; acr and amr of ID tokens are derived from this
+		Amr: []string{utils.AMRPassword},
 	})
 	if err != nil {
 		return utils.RespondWithError(
@@ -155,6 +157,35 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		)
 	}
 
+	// The provider endpoints identify the session by an opaque identifier of their own,
+	// sent on navigations from relying parties where the token cookies are not
+	opSessionID, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Internal server error",
+			utils.ErrorCodeInternalError,
+			"Could not generate tokens",
+			err,
+		)
+	}
+	err = h.store.CreateOPSession(c.Request().Context(), sqlc.CreateOPSessionParams{
+		SessionID:  sessionID,
+		Identifier: opSessionID,
+		ExpiresAt:  refreshTokenExpiry,
+	})
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Internal server error",
+			utils.ErrorCodeInternalError,
+			"Could not create session",
+			err,
+		)
+	}
+
 	res := LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -164,6 +195,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 
 	// set the access and refresh tokens in the response header
 	utils.SetTokensCookies(c, accessToken, refreshToken)
+	utils.SetOPSessionCookie(c, opSessionID, int(time.Until(refreshTokenExpiry).Seconds()))
 	return utils.RespondWithSuccess(
 		c,
 		utils.StatusCodeSuccess,
@@ -1,6 +1,12 @@
 package client
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+)
 
 // ==========
 // Client DTOs
@@ -8,63 +14,126 @@ import "time"
 
 // CreateClientRequest represents the request to create a new client
 type CreateClientRequest struct {
-	Name                 string   `json:"name" validate:"required,min=3,max=100"`
-	Description          string   `json:"description" validate:"max=500"`
-	Website              string   `json:"website" validate:"omitempty,url,max=255"`
-	RedirectURI          string   `json:"redirect_uri" validate:"required,url,max=255"`
+	Name        string `json:"name" validate:"required,min=3,max=100"`
+	Description string `json:"description" validate:"max=500"`
+	Website     string `json:"website" validate:"omitempty,url,max=255"`
+	// Redirect URIs are matched exactly, one per deployment of the client
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,redirect_uri,max=255"`
+	// Lets native apps use any port of a loopback redirect URI (RFC 8252 section 7.3)
+	AllowLoopbackRedirectPorts bool `json:"allow_loopback_redirect_ports"`
+	// Web origins allowed to call the token, userinfo and other provider endpoints from a browser
+	AllowedOrigins       []string `json:"allowed_origins" validate:"omitempty,dive,web_origin,max=255"`
 	IsPublic             bool     `json:"is_public"`
 	OIDCEnabled          bool     `json:"oidc_enabled"`
 	AllowedScopes        []string `json:"allowed_scopes" validate:"omitempty,dive,required"`
 	AllowedGrantTypes    []string `json:"allowed_grant_types" validate:"omitempty,dive,required"`
 	AllowedResponseTypes []string `json:"allowed_response_types" validate:"omitempty,dive,required"`
+	// Algorithm UserInfo responses are signed with; empty returns plain JSON
+	UserinfoSignedResponseAlg string `json:"userinfo_signed_response_alg" validate:"omitempty,oneof=RS256 ES256 EdDSA"`
+	// URIs the client may return to after RP-initiated logout
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris" validate:"omitempty,dive,redirect_uri,max=255"`
+	// Endpoint receiving logout tokens when a user's session ends
+	BackchannelLogoutURI string `json:"backchannel_logout_uri" validate:"omitempty,url,max=255"`
+	// Page loaded in an iframe when a user's session ends, with iss and sid when session_required is set
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri" validate:"omitempty,url,max=255"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannel_logout_session_required"`
+	// First-party clients are authorized without asking the user for consent
+	IsFirstParty bool `json:"is_first_party"`
+	// How the client authenticates at the token endpoint; public clients always use none
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method" validate:"omitempty,oneof=client_secret_basic client_secret_post client_secret_jwt private_key_jwt"`
+	// Public keys verifying private_key_jwt assertions and request objects, as a JWK Set or the URL publishing one
+	JWKS    string `json:"jwks" validate:"omitempty,json"`
+	JWKSURI string `json:"jwks_uri" validate:"omitempty,url,max=255"`
+	// Authorization requests must be pushed to /oauth2/par first (RFC 9126)
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests"`
+	// Authorization requests must be signed request objects (RFC 9101)
+	RequireSignedRequestObject bool `json:"require_signed_request_object"`
+	// URLs the server may fetch request objects of the client from
+	RequestURIs []string `json:"request_uris" validate:"omitempty,dive,url,startswith=https://,max=255"`
+	// Audiences and resources the client may exchange access tokens for (RFC 8693)
+	TokenExchangeAudiences []string `json:"token_exchange_audiences" validate:"omitempty,dive,required,max=255"`
 }
 
 // UpdateClientRequest represents the request to update an existing client
 type UpdateClientRequest struct {
-	Name                 string   `json:"name" validate:"required,min=3,max=100"`
-	Description          string   `json:"description" validate:"max=500"`
-	Website              string   `json:"website" validate:"omitempty,url,max=255"`
-	RedirectURI          string   `json:"redirect_uri" validate:"required,url,max=255"`
+	Name        string `json:"name" validate:"required,min=3,max=100"`
+	Description string `json:"description" validate:"max=500"`
+	Website     string `json:"website" validate:"omitempty,url,max=255"`
+	// Redirect URIs are matched exactly, one per deployment of the client
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,redirect_uri,max=255"`
+	// Lets native apps use any port of a loopback redirect URI (RFC 8252 section 7.3)
+	AllowLoopbackRedirectPorts bool `json:"allow_loopback_redirect_ports"`
+	// Web origins allowed to call the token, userinfo and other provider endpoints from a browser
+	AllowedOrigins       []string `json:"allowed_origins" validate:"omitempty,dive,web_origin,max=255"`
 	IsPublic             bool     `json:"is_public"`
 	OIDCEnabled          bool     `json:"oidc_enabled"`
 	AllowedScopes        []string `json:"allowed_scopes" validate:"omitempty,dive,required"`
 	AllowedGrantTypes    []string `json:"allowed_grant_types" validate:"omitempty,dive,required"`
 	AllowedResponseTypes []string `json:"allowed_response_types" validate:"omitempty,dive,required"`
+	// Algorithm UserInfo responses are signed with; empty returns plain JSON
+	UserinfoSignedResponseAlg string `json:"userinfo_signed_response_alg" validate:"omitempty,oneof=RS256 ES256 EdDSA"`
+	// URIs the client may return to after RP-initiated logout
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris" validate:"omitempty,dive,redirect_uri,max=255"`
+	// Endpoint receiving logout tokens when a user's session ends
+	BackchannelLogoutURI string `json:"backchannel_logout_uri" validate:"omitempty,url,max=255"`
+	// Page loaded in an iframe when a user's session ends, with iss and sid when session_required is set
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri" validate:"omitempty,url,max=255"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannel_logout_session_required"`
+	// First-party clients are authorized without asking the user for consent
+	IsFirstParty bool `json:"is_first_party"`
+	// How the client authenticates at the token endpoint; public clients always use none
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method" validate:"omitempty,oneof=client_secret_basic client_secret_post client_secret_jwt private_key_jwt"`
+	// Public keys verifying private_key_jwt assertions and request objects, as a JWK Set or the URL publishing one
+	JWKS    string `json:"jwks" validate:"omitempty,json"`
+	JWKSURI string `json:"jwks_uri" validate:"omitempty,url,max=255"`
+	// Authorization requests must be pushed to /oauth2/par first (RFC 9126)
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests"`
+	// Authorization requests must be signed request objects (RFC 9101)
+	RequireSignedRequestObject bool `json:"require_signed_request_object"`
+	// URLs the server may fetch request objects of the client from
+	RequestURIs []string `json:"request_uris" validate:"omitempty,dive,url,startswith=https://,max=255"`
+	// Audiences and resources the client may exchange access tokens for (RFC 8693)
+	TokenExchangeAudiences []string `json:"token_exchange_audiences" validate:"omitempty,dive,required,max=255"`
 }
 
 // ClientResponse represents the response for a client
 type ClientResponse struct {
-	ID                   int64     `json:"id"`
-	ClientID             string    `json:"client_id"`
-	Name                 string    `json:"name"`
-	Description          string    `json:"description"`
-	Website              string    `json:"website"`
-	RedirectURI          string    `json:"redirect_uri"`
-	IsPublic             bool      `json:"is_public"`
-	OIDCEnabled          bool      `json:"oidc_enabled"`
-	AllowedScopes        []string  `json:"allowed_scopes"`
-	AllowedGrantTypes    []string  `json:"allowed_grant_types"`
-	AllowedResponseTypes []string  `json:"allowed_response_types"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                                 int64     `json:"id"`
+	ClientID                           string    `json:"client_id"`
+	Name                               string    `json:"name"`
+	Description                        string    `json:"description"`
+	Website                            string    `json:"website"`
+	RedirectURIs                       []string  `json:"redirect_uris"`
+	AllowLoopbackRedirectPorts         bool      `json:"allow_loopback_redirect_ports"`
+	AllowedOrigins                     []string  `json:"allowed_origins"`
+	IsPublic                           bool      `json:"is_public"`
+	OIDCEnabled                        bool      `json:"oidc_enabled"`
+	AllowedScopes                      []string  `json:"allowed_scopes"`
+	AllowedGrantTypes                  []string  `json:"allowed_grant_types"`
+	AllowedResponseTypes               []string  `json:"allowed_response_types"`
+	UserinfoSignedResponseAlg          string    `json:"userinfo_signed_response_alg,omitempty"`
+	PostLogoutRedirectURIs             []string  `json:"post_logout_redirect_uris"`
+	BackchannelLogoutURI               string    `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI              string    `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired  bool      `json:"frontchannel_logout_session_required"`
+	IsFirstParty                       bool      `json:"is_first_party"`
+	TokenEndpointAuthMethod            string    `json:"token_endpoint_auth_method"`
+	JWKS                               string    `json:"jwks,omitempty"`
+	JWKSURI                            string    `json:"jwks_uri,omitempty"`
+	RequirePushedAuthorizationRequests bool      `json:"require_pushed_authorization_requests"`
+	RequireSignedRequestObject         bool      `json:"require_signed_request_object"`
+	RequestURIs                        []string  `json:"request_uris"`
+	TokenExchangeAudiences             []string  `json:"token_exchange_audiences"`
+	CreatedAt                          time.Time `json:"created_at"`
+	UpdatedAt                          time.Time `json:"updated_at"`
 }
 
-// ClientDetailResponse represents the detailed response for a client including the secret
+// ClientDetailResponse represents a client along with its plaintext secret.
+// It is only returned when the secret is created, the database keeps its hash.
 type ClientDetailResponse struct {
-	ID                   int64     `json:"id"`
-	ClientID             string    `json:"client_id"`
-	ClientSecret         string    `json:"client_secret"`
-	Name                 string    `json:"name"`
-	Description          string    `json:"description"`
-	Website              string    `json:"website"`
-	RedirectURI          string    `json:"redirect_uri"`
-	IsPublic             bool      `json:"is_public"`
-	OIDCEnabled          bool      `json:"oidc_enabled"`
-	AllowedScopes        []string  `json:"allowed_scopes"`
-	AllowedGrantTypes    []string  `json:"allowed_grant_types"`
-	AllowedResponseTypes []string  `json:"allowed_response_types"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ClientResponse
+	ClientSecret          string     `json:"client_secret"`
+	ClientSecretExpiresAt *time.Time `json:"client_secret_expires_at,omitempty"`
 }
 
 // ClientListResponse represents the response for a list of clients
@@ -72,3 +141,133 @@ type ClientListResponse struct {
 	Clients []ClientResponse `json:"clients"`
 	Total   int64            `json:"total"`
 }
+
+// newClientResponse converts a client to its response, leaving out the secret
+func newClientResponse(client sqlc.Client) ClientResponse {
+	return ClientResponse{
+		ID:                                 int64(client.ID),
+		ClientID:                           client.ClientID,
+		Name:                               client.Name,
+		Description:                        client.Description.String,
+		Website:                            client.Website.String,
+		RedirectURIs:                       client.RedirectUris,
+		AllowLoopbackRedirectPorts:         client.AllowLoopbackRedirectPorts,
+		AllowedOrigins:                     client.AllowedOrigins,
+		IsPublic:                           client.IsPublic,
+		OIDCEnabled:                        client.OidcEnabled,
+		AllowedScopes:                      client.AllowedScopes,
+		AllowedGrantTypes:                  client.AllowedGrantTypes,
+		AllowedResponseTypes:               client.AllowedResponseTypes,
+		UserinfoSignedResponseAlg:          client.UserinfoSignedResponseAlg.String,
+		PostLogoutRedirectURIs:             client.PostLogoutRedirectUris,
+		BackchannelLogoutURI:               client.BackchannelLogoutUri.String,
+		FrontchannelLogoutURI:              client.FrontchannelLogoutUri.String,
+		FrontchannelLogoutSessionRequired:  client.FrontchannelLogoutSessionRequired,
+		IsFirstParty:                       client.IsFirstParty,
+		TokenEndpointAuthMethod:            client.TokenEndpointAuthMethod,
+		JWKS:                               client.Jwks.String,
+		JWKSURI:                            client.JwksUri.String,
+		RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         client.RequireSignedRequestObject,
+		RequestURIs:                        client.RequestUris,
+		TokenExchangeAudiences:             client.TokenExchangeAudiences,
+		CreatedAt:                          client.CreatedAt,
+		UpdatedAt:                          client.UpdatedAt,
+	}
+}
+
+// BackchannelLogoutDeliveryResponse represents one logout token sent to a client
+type BackchannelLogoutDeliveryResponse struct {
+	ID          int64      `json:"id"`
+	LogoutURI   string     `json:"logout_uri"`
+	UserID      int64      `json:"user_id"`
+	SessionID   *int64     `json:"session_id,omitempty"`
+	Status      string     `json:"status"`
+	Attempts    int32      `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// BackchannelLogoutDeliveryListResponse represents the recent logout deliveries of a client
+type BackchannelLogoutDeliveryListResponse struct {
+	Deliveries []BackchannelLogoutDeliveryResponse `json:"deliveries"`
+}
+
+// RegenerateSecretRequest represents the options for replacing a client's secret.
+// Without a grace period the current secrets stop working immediately.
+type RegenerateSecretRequest struct {
+	// Seconds the newest current secret keeps working, so running instances can be redeployed
+	GracePeriod int64 `json:"grace_period" validate:"min=0,max=2592000"`
+	// Seconds until the new secret expires; it does not expire when omitted
+	ExpiresIn int64 `json:"expires_in" validate:"omitempty,min=300"`
+}
+
+// ClientSecretResponse describes a client secret without the secret itself
+type ClientSecretResponse struct {
+	ID         int64      `json:"id"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// ClientSecretListResponse represents the secrets of a client, newest first
+type ClientSecretListResponse struct {
+	Secrets []ClientSecretResponse `json:"secrets"`
+	Total   int64                  `json:"total"`
+}
+
+// newClientSecretResponse converts a stored secret to its response, leaving out the hash
+func newClientSecretResponse(secret sqlc.ClientSecret) ClientSecretResponse {
+	res := ClientSecretResponse{
+		ID:        int64(secret.ID),
+		Active:    !secret.ExpiresAt.Valid || secret.ExpiresAt.Time.After(time.Now()),
+		CreatedAt: secret.CreatedAt,
+	}
+	if secret.LastUsedAt.Valid {
+		res.LastUsedAt = &secret.LastUsedAt.Time
+	}
+	if secret.ExpiresAt.Valid {
+		res.ExpiresAt = &secret.ExpiresAt.Time
+	}
+	return res
+}
+
+// newClientDetailResponse returns a client along with the secret that was just issued to it
+func newClientDetailResponse(client sqlc.Client, secret string, stored sqlc.ClientSecret) ClientDetailResponse {
+	res := ClientDetailResponse{
+		ClientResponse: newClientResponse(client),
+		ClientSecret:   secret,
+	}
+	if stored.ExpiresAt.Valid {
+		res.ClientSecretExpiresAt = &stored.ExpiresAt.Time
+	}
+	return res
+}
+
+// nonNil returns an empty slice for a list omitted from the request,
+// since a nil slice would be stored as NULL in a NOT NULL array column
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// validateUserinfoSigningAlg checks that UserInfo responses can be signed with the
+// requested algorithm, which must be that of the active signing key
+func validateUserinfoSigningAlg(alg string) error {
+	if alg == "" {
+		return nil
+	}
+	active := utils.GetKeyStore().SigningAlgorithm()
+	if active == utils.SigningAlgHS256 {
+		return fmt.Errorf("UserInfo responses cannot be signed with the legacy HS256 algorithm")
+	}
+	if alg != active {
+		return fmt.Errorf("userinfo_signed_response_alg must be %s, the algorithm of the signing keys", active)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"database/sql"
 	"strconv"
+	"time"
 
 	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
@@ -46,31 +47,87 @@ func (h *ClientHandler) Create(c echo.Context) error {
 		return err
 	}
 
-	// Generate client ID and secret
-	clientID := uuid.New().String()
-	clientSecret, _ := utils.GenerateRandomString(32)
+	auth, err := utils.ResolveClientAuthentication(req.IsPublic, req.TokenEndpointAuthMethod, req.JWKS, req.JWKSURI)
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid client authentication",
+			utils.ErrorCodeValidationFailed,
+			err.Error(),
+			err,
+		)
+	}
 
-	// Create client in database
-	client, err := h.store.CreateClient(c.Request().Context(), sqlc.CreateClientParams{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		Name:         req.Name,
-		Description: sql.NullString{
-			String: req.Description,
-			Valid:  req.Description != "",
-		},
-		Website: sql.NullString{
-			String: req.Website,
-			Valid:  req.Website != "",
-		},
-		RedirectUri:          req.RedirectURI,
-		IsPublic:             req.IsPublic,
-		OidcEnabled:          req.OIDCEnabled,
-		AllowedScopes:        req.AllowedScopes,
-		AllowedGrantTypes:    req.AllowedGrantTypes,
-		AllowedResponseTypes: req.AllowedResponseTypes,
-	})
+	if err := validateUserinfoSigningAlg(req.UserinfoSignedResponseAlg); err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid UserInfo signing algorithm",
+			utils.ErrorCodeValidationFailed,
+			err.Error(),
+			err,
+		)
+	}
+
+	// Create the client with its first secret, only the hash of the secret is stored
+	ctx := c.Request().Context()
+	var (
+		client       sqlc.Client
+		clientSecret string
+		stored       sqlc.ClientSecret
+	)
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		client, err = q.CreateClient(ctx, sqlc.CreateClientParams{
+			ClientID: uuid.New().String(),
+			Name:     req.Name,
+			Description: sql.NullString{
+				String: req.Description,
+				Valid:  req.Description != "",
+			},
+			Website: sql.NullString{
+				String: req.Website,
+				Valid:  req.Website != "",
+			},
+			RedirectUris:         req.RedirectURIs,
+			IsPublic:             req.IsPublic,
+			OidcEnabled:          req.OIDCEnabled,
+			AllowedScopes:        req.AllowedScopes,
+			AllowedGrantTypes:    req.AllowedGrantTypes,
+			AllowedResponseTypes: req.AllowedResponseTypes,
+			UserinfoSignedResponseAlg: sql.NullString{
+				String: req.UserinfoSignedResponseAlg,
+				Valid:  req.UserinfoSignedResponseAlg != "",
+			},
+			PostLogoutRedirectUris: nonNil(req.PostLogoutRedirectURIs),
+			BackchannelLogoutUri: sql.NullString{
+				String: req.BackchannelLogoutURI,
+				Valid:  req.BackchannelLogoutURI != "",
+			},
+			FrontchannelLogoutUri: sql.NullString{
+				String: req.FrontchannelLogoutURI,
+				Valid:  req.FrontchannelLogoutURI != "",
+			},
+			FrontchannelLogoutSessionRequired:  req.FrontchannelLogoutSessionRequired,
+			IsFirstParty:                       req.IsFirstParty,
+			AllowedOrigins:                     nonNil(req.AllowedOrigins),
+			AllowLoopbackRedirectPorts:         req.AllowLoopbackRedirectPorts,
+			TokenEndpointAuthMethod:            auth.Method,
+			Jwks:                               auth.JWKS,
+			JwksUri:                            auth.JWKSURI,
+			RequirePushedAuthorizationRequests: req.RequirePushedAuthorizationRequests,
+			RequireSignedRequestObject:         req.RequireSignedRequestObject,
+			RequestUris:                        nonNil(req.RequestURIs),
+			TokenExchangeAudiences:             nonNil(req.TokenExchangeAudiences),
+		})
+		if err != nil {
+			return err
+		}
 
+		clientSecret, stored, err = h.issueSecret(ctx, q, client, 0, 0)
+		return err
+	})
 	if err != nil {
 		return utils.RespondWithError(
 			c,
@@ -82,29 +139,11 @@ func (h *ClientHandler) Create(c echo.Context) error {
 		)
 	}
 
-	// Prepare response
-	res := ClientDetailResponse{
-		ID:                   int64(client.ID),
-		ClientID:             client.ClientID,
-		ClientSecret:         client.ClientSecret,
-		Name:                 client.Name,
-		Description:          client.Description.String,
-		Website:              client.Website.String,
-		RedirectURI:          client.RedirectUri,
-		IsPublic:             client.IsPublic,
-		OIDCEnabled:          client.OidcEnabled,
-		AllowedScopes:        client.AllowedScopes,
-		AllowedGrantTypes:    client.AllowedGrantTypes,
-		AllowedResponseTypes: client.AllowedResponseTypes,
-		CreatedAt:            client.CreatedAt,
-		UpdatedAt:            client.UpdatedAt,
-	}
-
 	return utils.RespondWithSuccess(
 		c,
 		utils.StatusCodeCreated,
 		"Client created successfully",
-		res,
+		newClientDetailResponse(client, clientSecret, stored),
 	)
 }
 
@@ -125,21 +164,7 @@ func (h *ClientHandler) GetAll(c echo.Context) error {
 	// Prepare response
 	clientResponses := make([]ClientResponse, 0, len(clients))
 	for _, client := range clients {
-		clientResponses = append(clientResponses, ClientResponse{
-			ID:                   int64(client.ID),
-			ClientID:             client.ClientID,
-			Name:                 client.Name,
-			Description:          client.Description.String,
-			Website:              client.Website.String,
-			RedirectURI:          client.RedirectUri,
-			IsPublic:             client.IsPublic,
-			OIDCEnabled:          client.OidcEnabled,
-			AllowedScopes:        client.AllowedScopes,
-			AllowedGrantTypes:    client.AllowedGrantTypes,
-			AllowedResponseTypes: client.AllowedResponseTypes,
-			CreatedAt:            client.CreatedAt,
-			UpdatedAt:            client.UpdatedAt,
-		})
+		clientResponses = append(clientResponses, newClientResponse(client))
 	}
 
 	return utils.RespondWithSuccess(
@@ -203,21 +228,7 @@ func (h *ClientHandler) GetByID(c echo.Context) error {
 	}
 
 	// Prepare response (without secret)
-	res := ClientResponse{
-		ID:                   int64(client.ID),
-		ClientID:             client.ClientID,
-		Name:                 client.Name,
-		Description:          client.Description.String,
-		Website:              client.Website.String,
-		RedirectURI:          client.RedirectUri,
-		IsPublic:             client.IsPublic,
-		OIDCEnabled:          client.OidcEnabled,
-		AllowedScopes:        client.AllowedScopes,
-		AllowedGrantTypes:    client.AllowedGrantTypes,
-		AllowedResponseTypes: client.AllowedResponseTypes,
-		CreatedAt:            client.CreatedAt,
-		UpdatedAt:            client.UpdatedAt,
-	}
+	res := newClientResponse(client)
 
 	return utils.RespondWithSuccess(
 		c,
@@ -271,6 +282,29 @@ func (h *ClientHandler) Update(c echo.Context) error {
 		return err
 	}
 
+	auth, err := utils.ResolveClientAuthentication(req.IsPublic, req.TokenEndpointAuthMethod, req.JWKS, req.JWKSURI)
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid client authentication",
+			utils.ErrorCodeValidationFailed,
+			err.Error(),
+			err,
+		)
+	}
+
+	if err := validateUserinfoSigningAlg(req.UserinfoSignedResponseAlg); err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid UserInfo signing algorithm",
+			utils.ErrorCodeValidationFailed,
+			err.Error(),
+			err,
+		)
+	}
+
 	// Check if client exists
 	_, err = h.store.GetClientByID(c.Request().Context(), int32(idInt))
 	if err != nil {
@@ -294,26 +328,58 @@ func (h *ClientHandler) Update(c echo.Context) error {
 		)
 	}
 
-	// Update client
-	client, err := h.store.UpdateClient(c.Request().Context(), sqlc.UpdateClientParams{
-		ID:   int32(idInt),
-		Name: req.Name,
-		Description: sql.NullString{
-			String: req.Description,
-			Valid:  req.Description != "",
-		},
-		Website: sql.NullString{
-			String: req.Website,
-			Valid:  req.Website != "",
-		},
-		RedirectUri:          req.RedirectURI,
-		IsPublic:             req.IsPublic,
-		OidcEnabled:          req.OIDCEnabled,
-		AllowedScopes:        req.AllowedScopes,
-		AllowedGrantTypes:    req.AllowedGrantTypes,
-		AllowedResponseTypes: req.AllowedResponseTypes,
+	// Update client. Encrypted secret copies are only kept while the client uses client_secret_jwt
+	ctx := c.Request().Context()
+	var client sqlc.Client
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		client, err = q.UpdateClient(ctx, sqlc.UpdateClientParams{
+			ID:   int32(idInt),
+			Name: req.Name,
+			Description: sql.NullString{
+				String: req.Description,
+				Valid:  req.Description != "",
+			},
+			Website: sql.NullString{
+				String: req.Website,
+				Valid:  req.Website != "",
+			},
+			RedirectUris:         req.RedirectURIs,
+			IsPublic:             req.IsPublic,
+			OidcEnabled:          req.OIDCEnabled,
+			AllowedScopes:        req.AllowedScopes,
+			AllowedGrantTypes:    req.AllowedGrantTypes,
+			AllowedResponseTypes: req.AllowedResponseTypes,
+			UserinfoSignedResponseAlg: sql.NullString{
+				String: req.UserinfoSignedResponseAlg,
+				Valid:  req.UserinfoSignedResponseAlg != "",
+			},
+			PostLogoutRedirectUris: nonNil(req.PostLogoutRedirectURIs),
+			BackchannelLogoutUri: sql.NullString{
+				String: req.BackchannelLogoutURI,
+				Valid:  req.BackchannelLogoutURI != "",
+			},
+			FrontchannelLogoutUri: sql.NullString{
+				String: req.FrontchannelLogoutURI,
+				Valid:  req.FrontchannelLogoutURI != "",
+			},
+			FrontchannelLogoutSessionRequired:  req.FrontchannelLogoutSessionRequired,
+			IsFirstParty:                       req.IsFirstParty,
+			AllowedOrigins:                     nonNil(req.AllowedOrigins),
+			AllowLoopbackRedirectPorts:         req.AllowLoopbackRedirectPorts,
+			TokenEndpointAuthMethod:            auth.Method,
+			Jwks:                               auth.JWKS,
+			JwksUri:                            auth.JWKSURI,
+			RequirePushedAuthorizationRequests: req.RequirePushedAuthorizationRequests,
+			RequireSignedRequestObject:         req.RequireSignedRequestObject,
+			RequestUris:                        nonNil(req.RequestURIs),
+			TokenExchangeAudiences:             nonNil(req.TokenExchangeAudiences),
+		})
+		if err != nil || client.TokenEndpointAuthMethod == utils.AuthMethodClientSecretJWT {
+			return err
+		}
+		return q.ClearEncryptedClientSecrets(ctx, client.ClientID)
 	})
-
 	if err != nil {
 		return utils.RespondWithError(
 			c,
@@ -326,21 +392,7 @@ func (h *ClientHandler) Update(c echo.Context) error {
 	}
 
 	// Prepare response
-	res := ClientResponse{
-		ID:                   int64(client.ID),
-		ClientID:             client.ClientID,
-		Name:                 client.Name,
-		Description:          client.Description.String,
-		Website:              client.Website.String,
-		RedirectURI:          client.RedirectUri,
-		IsPublic:             client.IsPublic,
-		OIDCEnabled:          client.OidcEnabled,
-		AllowedScopes:        client.AllowedScopes,
-		AllowedGrantTypes:    client.AllowedGrantTypes,
-		AllowedResponseTypes: client.AllowedResponseTypes,
-		CreatedAt:            client.CreatedAt,
-		UpdatedAt:            client.UpdatedAt,
-	}
+	res := newClientResponse(client)
 
 	return utils.RespondWithSuccess(
 		c,
@@ -421,35 +473,79 @@ func (h *ClientHandler) Delete(c echo.Context) error {
 	)
 }
 
-// RegenerateSecret handles regenerating a client's secret
+// RegenerateSecret handles regenerating a client's secret.
+// With a grace period the previous secret keeps working while the client is redeployed.
 func (h *ClientHandler) RegenerateSecret(c echo.Context) error {
-	id := c.Param("id")
-	if id == "" {
+	client, err := h.clientFromParam(c)
+	if client == nil {
+		return err
+	}
+
+	// The options are optional, an empty body replaces the secret immediately
+	req := new(RegenerateSecretRequest)
+	if err := c.Bind(req); err != nil {
 		return utils.RespondWithError(
 			c,
 			utils.StatusCodeBadRequest,
-			"Invalid client ID",
+			"Invalid request data",
 			utils.ErrorCodeInvalidRequest,
-			"Client ID is required",
-			nil,
+			"Could not parse request body",
+			err,
 		)
 	}
 
-	// Convert string ID to int32
-	idInt, err := strconv.ParseInt(id, 10, 32)
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	var (
+		newSecret string
+		stored    sqlc.ClientSecret
+	)
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		newSecret, stored, err = h.issueSecret(ctx, q, *client,
+			time.Duration(req.GracePeriod)*time.Second,
+			time.Duration(req.ExpiresIn)*time.Second,
+		)
+		return err
+	})
 	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to regenerate client secret",
+			utils.ErrorCodeDatabaseError,
+			"Could not regenerate client secret",
+			err,
+		)
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Client secret regenerated successfully",
+		newClientDetailResponse(*client, newSecret, stored),
+	)
+}
+
+// RegenerateSecretByClientID handles regenerating a client's secret using the client_id
+func (h *ClientHandler) RegenerateSecretByClientID(c echo.Context) error {
+	clientID := c.Param("client_id")
+	if clientID == "" {
 		return utils.RespondWithError(
 			c,
 			utils.StatusCodeBadRequest,
 			"Invalid client ID",
 			utils.ErrorCodeInvalidRequest,
-			"Client ID must be a valid integer",
-			err,
+			"Client ID is required",
+			nil,
 		)
 	}
 
 	// Check if client exists
-	_, err = h.store.GetClientByID(c.Request().Context(), int32(idInt))
+	client, err := h.store.GetClientByClientID(c.Request().Context(), clientID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return utils.RespondWithError(
@@ -457,7 +553,7 @@ func (h *ClientHandler) RegenerateSecret(c echo.Context) error {
 				utils.StatusCodeNotFound,
 				"Client not found",
 				utils.ErrorCodeResourceNotFound,
-				"No client found with the provided ID",
+				"No client found with the provided client ID",
 				nil,
 			)
 		}
@@ -471,25 +567,17 @@ func (h *ClientHandler) RegenerateSecret(c echo.Context) error {
 		)
 	}
 
-	// Generate new secret
-	newSecret, err := utils.GenerateRandomString(32)
-	if err != nil {
-		return utils.RespondWithError(
-			c,
-			utils.StatusCodeInternalError,
-			"Failed to generate client secret",
-			utils.ErrorCodeInternalError,
-			"Could not generate client secret",
-			err,
-		)
-	}
-
-	// Update client secret
-	client, err := h.store.UpdateClientSecret(c.Request().Context(), sqlc.UpdateClientSecretParams{
-		ID:           int32(idInt),
-		ClientSecret: newSecret,
+	// A lost secret cannot be trusted anymore, so it is replaced immediately
+	ctx := c.Request().Context()
+	var (
+		newSecret string
+		stored    sqlc.ClientSecret
+	)
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		newSecret, stored, err = h.issueSecret(ctx, q, client, 0, 0)
+		return err
 	})
-
 	if err != nil {
 		return utils.RespondWithError(
 			c,
@@ -501,48 +589,30 @@ func (h *ClientHandler) RegenerateSecret(c echo.Context) error {
 		)
 	}
 
-	// Prepare response
-	res := ClientDetailResponse{
-		ID:                   int64(client.ID),
-		ClientID:             client.ClientID,
-		ClientSecret:         client.ClientSecret,
-		Name:                 client.Name,
-		Description:          client.Description.String,
-		Website:              client.Website.String,
-		RedirectURI:          client.RedirectUri,
-		IsPublic:             client.IsPublic,
-		OIDCEnabled:          client.OidcEnabled,
-		AllowedScopes:        client.AllowedScopes,
-		AllowedGrantTypes:    client.AllowedGrantTypes,
-		AllowedResponseTypes: client.AllowedResponseTypes,
-		CreatedAt:            client.CreatedAt,
-		UpdatedAt:            client.UpdatedAt,
-	}
-
 	return utils.RespondWithSuccess(
 		c,
 		utils.StatusCodeSuccess,
 		"Client secret regenerated successfully",
-		res,
+		newClientDetailResponse(client, newSecret, stored),
 	)
 }
 
-// RegenerateSecretByClientID handles regenerating a client's secret using the client_id
-func (h *ClientHandler) RegenerateSecretByClientID(c echo.Context) error {
-	clientID := c.Param("client_id")
-	if clientID == "" {
+// GetBackchannelLogoutDeliveries lists the most recent logout tokens sent to a client,
+// so that failing back-channel logout endpoints can be spotted
+func (h *ClientHandler) GetBackchannelLogoutDeliveries(c echo.Context) error {
+	idInt, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
 		return utils.RespondWithError(
 			c,
 			utils.StatusCodeBadRequest,
 			"Invalid client ID",
 			utils.ErrorCodeInvalidRequest,
-			"Client ID is required",
-			nil,
+			"Client ID must be a valid integer",
+			err,
 		)
 	}
 
-	// Check if client exists
-	client, err := h.store.GetClientByClientID(c.Request().Context(), clientID)
+	client, err := h.store.GetClientByID(c.Request().Context(), int32(idInt))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return utils.RespondWithError(
@@ -550,72 +620,65 @@ func (h *ClientHandler) RegenerateSecretByClientID(c echo.Context) error {
 				utils.StatusCodeNotFound,
 				"Client not found",
 				utils.ErrorCodeResourceNotFound,
-				"No client found with the provided client ID",
+				"No client found with the provided ID",
 				nil,
 			)
 		}
 		return utils.RespondWithError(
 			c,
 			utils.StatusCodeInternalError,
-			"Failed to check client existence",
+			"Failed to retrieve client",
 			utils.ErrorCodeDatabaseError,
-			"Could not check if client exists",
-			err,
-		)
-	}
-
-	// Generate new secret
-	newSecret, err := utils.GenerateRandomString(32)
-	if err != nil {
-		return utils.RespondWithError(
-			c,
-			utils.StatusCodeInternalError,
-			"Failed to generate client secret",
-			utils.ErrorCodeInternalError,
-			"Could not generate client secret",
+			"Could not retrieve client",
 			err,
 		)
 	}
 
-	// Update client secret
-	updatedClient, err := h.store.UpdateClientSecret(c.Request().Context(), sqlc.UpdateClientSecretParams{
-		ID:           client.ID,
-		ClientSecret: newSecret,
+	deliveries, err := h.store.ListBackchannelLogoutDeliveriesByClient(c.Request().Context(), sqlc.ListBackchannelLogoutDeliveriesByClientParams{
+		ClientID: client.ClientID,
+		Limit:    100,
 	})
-
 	if err != nil {
 		return utils.RespondWithError(
 			c,
 			utils.StatusCodeInternalError,
-			"Failed to regenerate client secret",
+			"Failed to retrieve logout deliveries",
 			utils.ErrorCodeDatabaseError,
-			"Could not regenerate client secret",
+			"Could not retrieve back-channel logout deliveries",
 			err,
 		)
 	}
 
-	// Prepare response
-	res := ClientDetailResponse{
-		ID:                   int64(updatedClient.ID),
-		ClientID:             updatedClient.ClientID,
-		ClientSecret:         updatedClient.ClientSecret,
-		Name:                 updatedClient.Name,
-		Description:          updatedClient.Description.String,
-		Website:              updatedClient.Website.String,
-		RedirectURI:          updatedClient.RedirectUri,
-		IsPublic:             updatedClient.IsPublic,
-		OIDCEnabled:          updatedClient.OidcEnabled,
-		AllowedScopes:        updatedClient.AllowedScopes,
-		AllowedGrantTypes:    updatedClient.AllowedGrantTypes,
-		AllowedResponseTypes: updatedClient.AllowedResponseTypes,
-		CreatedAt:            updatedClient.CreatedAt,
-		UpdatedAt:            updatedClient.UpdatedAt,
+	res := BackchannelLogoutDeliveryListResponse{
+		Deliveries: make([]BackchannelLogoutDeliveryResponse, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		item := BackchannelLogoutDeliveryResponse{
+			ID:        int64(delivery.ID),
+			LogoutURI: delivery.LogoutUri,
+			UserID:    int64(delivery.UserID),
+			Status:    delivery.Status,
+			Attempts:  delivery.Attempts,
+			LastError: delivery.LastError.String,
+			CreatedAt: delivery.CreatedAt,
+		}
+		if delivery.SessionID.Valid {
+			sessionID := int64(delivery.SessionID.Int32)
+			item.SessionID = &sessionID
+		}
+		if delivery.Status == utils.DeliveryStatePending {
+			item.NextAttempt = &delivery.NextAttemptAt
+		}
+		if delivery.DeliveredAt.Valid {
+			item.DeliveredAt = &delivery.DeliveredAt.Time
+		}
+		res.Deliveries = append(res.Deliveries, item)
 	}
 
 	return utils.RespondWithSuccess(
 		c,
 		utils.StatusCodeSuccess,
-		"Client secret regenerated successfully",
+		"Logout deliveries retrieved successfully",
 		res,
 	)
 }
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// issueSecret generates a new secret for a client and replaces its current secrets.
+// With a grace period the newest current secret keeps working until the period ends,
+// so a client never has more than two valid secrets; every other secret is removed.
+// It returns the plaintext secret, which is only stored encrypted for clients using
+// client_secret_jwt, since verifying their assertions needs the secret itself.
+func (h *ClientHandler) issueSecret(ctx context.Context, q *sqlc.Queries, client sqlc.Client, gracePeriod, lifetime time.Duration) (string, sqlc.ClientSecret, error) {
+	clientID := client.ClientID
+	secret, secretHash, err := utils.GenerateClientSecret()
+	if err != nil {
+		return "", sqlc.ClientSecret{}, err
+	}
+
+	var secretEncrypted []byte
+	if client.TokenEndpointAuthMethod == utils.AuthMethodClientSecretJWT {
+		secretEncrypted, err = utils.Encrypt(utils.ClientSecretEncryptionKey(h.config), []byte(secret))
+		if err != nil {
+			return "", sqlc.ClientSecret{}, err
+		}
+	}
+
+	if err := q.LockClientSecrets(ctx, clientID); err != nil {
+		return "", sqlc.ClientSecret{}, err
+	}
+
+	current, err := q.ListValidClientSecrets(ctx, clientID)
+	if err != nil {
+		return "", sqlc.ClientSecret{}, err
+	}
+
+	if gracePeriod > 0 && len(current) > 0 {
+		previous := current[0]
+		err = q.DeleteOtherClientSecrets(ctx, sqlc.DeleteOtherClientSecretsParams{
+			ClientID: clientID,
+			ID:       previous.ID,
+		})
+		if err != nil {
+			return "", sqlc.ClientSecret{}, err
+		}
+		err = q.ExpireClientSecret(ctx, sqlc.ExpireClientSecretParams{
+			ExpiresAt: time.Now().Add(gracePeriod),
+			ID:        previous.ID,
+		})
+	} else {
+		err = q.DeleteClientSecrets(ctx, clientID)
+	}
+	if err != nil {
+		return "", sqlc.ClientSecret{}, err
+	}
+
+	expiresAt := sql.NullTime{}
+	if lifetime > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(lifetime), Valid: true}
+	}
+	stored, err := q.CreateClientSecret(ctx, sqlc.CreateClientSecretParams{
+		ClientID:        clientID,
+		SecretHash:      secretHash,
+		ExpiresAt:       expiresAt,
+		SecretEncrypted: secretEncrypted,
+	})
+	if err != nil {
+		return "", sqlc.ClientSecret{}, err
+	}
+
+	return secret, stored, nil
+}
+
+// clientFromParam loads the client identified by the id path parameter,
+// responding with an error when it is invalid or unknown
+func (h *ClientHandler) clientFromParam(c echo.Context) (*sqlc.Client, error) {
+	idInt, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid client ID",
+			utils.ErrorCodeInvalidRequest,
+			"Client ID must be a valid integer",
+			err,
+		)
+	}
+
+	client, err := h.store.GetClientByID(c.Request().Context(), int32(idInt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, utils.RespondWithError(
+				c,
+				utils.StatusCodeNotFound,
+				"Client not found",
+				utils.ErrorCodeResourceNotFound,
+				"No client found with the provided ID",
+				nil,
+			)
+		}
+		return nil, utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to retrieve client",
+			utils.ErrorCodeDatabaseError,
+			"Could not retrieve client",
+			err,
+		)
+	}
+	return &client, nil
+}
+
+// GetSecrets handles listing the secrets of a client, including the ones being rotated out
+func (h *ClientHandler) GetSecrets(c echo.Context) error {
+	client, err := h.clientFromParam(c)
+	if client == nil {
+		return err
+	}
+
+	secrets, err := h.store.ListClientSecrets(c.Request().Context(), client.ClientID)
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to retrieve client secrets",
+			utils.ErrorCodeDatabaseError,
+			"Could not retrieve client secrets",
+			err,
+		)
+	}
+
+	res := make([]ClientSecretResponse, 0, len(secrets))
+	for _, secret := range secrets {
+		res = append(res, newClientSecretResponse(secret))
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Client secrets retrieved successfully",
+		ClientSecretListResponse{
+			Secrets: res,
+			Total:   int64(len(res)),
+		},
+	)
+}
+
+// RevokeSecret handles revoking one secret of a client before it expires
+func (h *ClientHandler) RevokeSecret(c echo.Context) error {
+	client, err := h.clientFromParam(c)
+	if client == nil {
+		return err
+	}
+
+	secretID, err := strconv.ParseInt(c.Param("secret_id"), 10, 32)
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeBadRequest,
+			"Invalid secret ID",
+			utils.ErrorCodeInvalidRequest,
+			"Secret ID must be a valid integer",
+			err,
+		)
+	}
+
+	deleted, err := h.store.DeleteClientSecret(c.Request().Context(), sqlc.DeleteClientSecretParams{
+		ID:       int32(secretID),
+		ClientID: client.ClientID,
+	})
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to revoke client secret",
+			utils.ErrorCodeDatabaseError,
+			"Could not revoke client secret",
+			err,
+		)
+	}
+	if deleted == 0 {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeNotFound,
+			"Client secret not found",
+			utils.ErrorCodeResourceNotFound,
+			"No secret found with the provided ID for this client",
+			nil,
+		)
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Client secret revoked successfully",
+		nil,
+	)
+}
@@ -0,0 +1,23 @@
+package consent
+
+import "time"
+
+// ==========
+// Consent DTOs
+// ==========
+
+// ConsentResponse describes the scopes a user granted to a third-party client
+type ConsentResponse struct {
+	ClientID      string    `json:"client_id"`
+	ClientName    string    `json:"client_name"`
+	ClientWebsite string    `json:"client_website,omitempty"`
+	Scopes        []string  `json:"scopes"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ConsentListResponse represents the response for listing a user's consents
+type ConsentListResponse struct {
+	Consents []ConsentResponse `json:"consents"`
+	Total    int64             `json:"total"`
+}
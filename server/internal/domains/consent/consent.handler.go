@@ -0,0 +1,125 @@
+package consent
+
+import (
+	"database/sql"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// ConsentHandler handles the requests of users managing the access they granted to clients
+type ConsentHandler struct {
+	store  *db.Store
+	config *config.Config
+}
+
+// NewConsentHandler creates a new consent handler
+func NewConsentHandler(ah *domains.AppHandlers) *ConsentHandler {
+	return &ConsentHandler{
+		store:  ah.Store,
+		config: ah.Cfg,
+	}
+}
+
+// GetAll handles listing the clients the current user granted access to
+func (h *ConsentHandler) GetAll(c echo.Context) error {
+	userID, _ := c.Get("user_id").(int64)
+
+	consents, err := h.store.ListOIDCConsentsByUser(c.Request().Context(), int32(userID))
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to retrieve consents",
+			utils.ErrorCodeDatabaseError,
+			"Could not retrieve consents",
+			err,
+		)
+	}
+
+	res := make([]ConsentResponse, 0, len(consents))
+	for _, consent := range consents {
+		res = append(res, ConsentResponse{
+			ClientID:      consent.ClientID,
+			ClientName:    consent.ClientName,
+			ClientWebsite: consent.ClientWebsite.String,
+			Scopes:        consent.Scopes,
+			CreatedAt:     consent.CreatedAt,
+			UpdatedAt:     consent.UpdatedAt,
+		})
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Consents retrieved successfully",
+		ConsentListResponse{
+			Consents: res,
+			Total:    int64(len(res)),
+		},
+	)
+}
+
+// Revoke handles withdrawing the access the current user granted to a client.
+// The client's tokens for the user are revoked with it, so the next
+// authorization asks for consent again.
+func (h *ConsentHandler) Revoke(c echo.Context) error {
+	userID, _ := c.Get("user_id").(int64)
+	clientID := c.Param("client_id")
+	ctx := c.Request().Context()
+
+	err := h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		deleted, err := q.DeleteOIDCConsent(ctx, sqlc.DeleteOIDCConsentParams{
+			ClientID: clientID,
+			UserID:   int32(userID),
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return sql.ErrNoRows
+		}
+
+		if err := q.RevokeAllClientUserRefreshTokens(ctx, sqlc.RevokeAllClientUserRefreshTokensParams{
+			ClientID: clientID,
+			UserID:   int32(userID),
+		}); err != nil {
+			return err
+		}
+		return q.RevokeAllClientUserAccessTokens(ctx, sqlc.RevokeAllClientUserAccessTokensParams{
+			ClientID: clientID,
+			UserID:   sql.NullInt32{Int32: int32(userID), Valid: true},
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithError(
+				c,
+				utils.StatusCodeNotFound,
+				"Consent not found",
+				utils.ErrorCodeResourceNotFound,
+				"You have not granted access to this client",
+				nil,
+			)
+		}
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to revoke consent",
+			utils.ErrorCodeDatabaseError,
+			"Could not revoke consent",
+			err,
+		)
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Consent revoked successfully",
+		nil,
+	)
+}
@@ -0,0 +1,24 @@
+package keys
+
+import "time"
+
+// ==========
+// Signing Key DTOs
+// ==========
+
+// SigningKeyResponse describes a signing key without its private key
+type SigningKeyResponse struct {
+	Kid         string     `json:"kid"`
+	Algorithm   string     `json:"algorithm"`
+	State       string     `json:"state"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+	RetiringAt  *time.Time `json:"retiring_at,omitempty"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+}
+
+// SigningKeyListResponse represents the response for listing signing keys
+type SigningKeyListResponse struct {
+	Keys  []SigningKeyResponse `json:"keys"`
+	Total int64                `json:"total"`
+}
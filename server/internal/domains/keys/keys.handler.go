@@ -0,0 +1,163 @@
+package keys
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// KeysHandler handles the signing key administration requests
+type KeysHandler struct {
+	store  *db.Store
+	config *config.Config
+}
+
+// NewKeysHandler creates a new signing key handler
+func NewKeysHandler(ah *domains.AppHandlers) *KeysHandler {
+	return &KeysHandler{
+		store:  ah.Store,
+		config: ah.Cfg,
+	}
+}
+
+// rotator returns the key rotator, responding with an error when rotation is disabled
+func (h *KeysHandler) rotator(c echo.Context) (*utils.KeyRotator, error) {
+	rotator := utils.GetKeyRotator()
+	if rotator == nil {
+		return nil, utils.RespondWithError(
+			c,
+			utils.StatusCodeConflict,
+			"Key rotation unavailable",
+			utils.ErrorCodeInvalidRequest,
+			"Signing keys are not managed in the database with the configured algorithm",
+			nil,
+		)
+	}
+	return rotator, nil
+}
+
+// GetAll handles listing all signing keys
+func (h *KeysHandler) GetAll(c echo.Context) error {
+	rotator, err := h.rotator(c)
+	if rotator == nil {
+		return err
+	}
+
+	keys, err := rotator.List(c.Request().Context())
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to retrieve signing keys",
+			utils.ErrorCodeDatabaseError,
+			"Could not retrieve signing keys",
+			err,
+		)
+	}
+
+	res := make([]SigningKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, toSigningKeyResponse(key))
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Signing keys retrieved successfully",
+		SigningKeyListResponse{
+			Keys:  res,
+			Total: int64(len(res)),
+		},
+	)
+}
+
+// Rotate handles activating a new signing key immediately
+func (h *KeysHandler) Rotate(c echo.Context) error {
+	rotator, err := h.rotator(c)
+	if rotator == nil {
+		return err
+	}
+
+	key, err := rotator.Rotate(c.Request().Context())
+	if err != nil {
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to rotate signing key",
+			utils.ErrorCodeInternalError,
+			"Could not rotate signing key",
+			err,
+		)
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeCreated,
+		"Signing key rotated successfully",
+		toSigningKeyResponse(key),
+	)
+}
+
+// Retire handles retiring a pending or retiring signing key before its schedule
+func (h *KeysHandler) Retire(c echo.Context) error {
+	rotator, err := h.rotator(c)
+	if rotator == nil {
+		return err
+	}
+
+	kid := c.Param("kid")
+	key, err := rotator.Retire(c.Request().Context(), kid)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithError(
+				c,
+				utils.StatusCodeNotFound,
+				"Signing key not found",
+				utils.ErrorCodeResourceNotFound,
+				"No pending or retiring signing key found with the provided kid; rotate the active key before retiring it",
+				nil,
+			)
+		}
+		return utils.RespondWithError(
+			c,
+			utils.StatusCodeInternalError,
+			"Failed to retire signing key",
+			utils.ErrorCodeDatabaseError,
+			"Could not retire signing key",
+			err,
+		)
+	}
+
+	return utils.RespondWithSuccess(
+		c,
+		utils.StatusCodeSuccess,
+		"Signing key retired successfully",
+		toSigningKeyResponse(key),
+	)
+}
+
+// toSigningKeyResponse converts a stored key to its response, leaving out the private key
+func toSigningKeyResponse(key sqlc.SigningKey) SigningKeyResponse {
+	nullTime := func(t sql.NullTime) *time.Time {
+		if !t.Valid {
+			return nil
+		}
+		return &t.Time
+	}
+
+	return SigningKeyResponse{
+		Kid:         key.Kid,
+		Algorithm:   key.Algorithm,
+		State:       key.State,
+		CreatedAt:   key.CreatedAt,
+		ActivatedAt: nullTime(key.ActivatedAt),
+		RetiringAt:  nullTime(key.RetiringAt),
+		RetiredAt:   nullTime(key.RetiredAt),
+	}
+}
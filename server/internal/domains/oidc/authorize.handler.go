@@ -0,0 +1,364 @@
+package oidc
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Values of the prompt parameter (OpenID Connect Core section 3.1.2.1)
+const (
+	promptNone          = "none"
+	promptLogin         = "login"
+	promptConsent       = "consent"
+	promptSelectAccount = "select_account" // A user agent holds a single session, so there is nothing to select
+)
+
+// supportedPrompts are the prompt values the authorization endpoint understands
+var supportedPrompts = []string{promptNone, promptLogin, promptConsent, promptSelectAccount}
+
+// Authorize handles the OAuth 2.0 authorization endpoint (GET and POST /oauth2/authorize).
+// Only the authorization code flow is supported.
+func (h *OIDCHandler) Authorize(c echo.Context) error {
+	req := new(AuthorizeRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse authorization request",
+		)
+	}
+
+	client, scopes, err := h.validateAuthorizeRequest(c, req)
+	if client == nil {
+		return err
+	}
+
+	// The user must be signed in to CentralAuth before a code can be issued
+	session, ok, err := h.currentSession(c)
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not check the user session", req.State)
+	}
+	if ok && req.reauthenticationRequired(session) {
+		ok = false
+	}
+	if !ok {
+		// prompt=none asks for a response without any user interaction (OpenID Connect Core section 3.1.2.1)
+		if req.hasPrompt(promptNone) {
+			return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorLoginRequired, "The user must sign in", req.State)
+		}
+		return h.redirectToLogin(c, req)
+	}
+
+	if !acrSatisfied(strings.Fields(req.ACRValues), sessionACR(session.Amr)) {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorUnmetAuthenticationRequirements, "The session does not meet the requested authentication context class", req.State)
+	}
+
+	user, err := h.authorizingUser(c, req, session)
+	if user == nil {
+		return err
+	}
+
+	// Third-party clients only receive what the user agreed to share with them
+	if !client.IsFirstParty {
+		consented, err := h.hasConsent(c.Request().Context(), client.ClientID, user.ID, scopes)
+		if err != nil {
+			return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not check the user's consent", req.State)
+		}
+		if !consented || req.hasPrompt(promptConsent) {
+			if req.hasPrompt(promptNone) {
+				return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorConsentRequired, "The user has not consented to the requested scopes", req.State)
+			}
+			return h.renderConsent(c, client, req, scopes)
+		}
+	}
+
+	return h.issueAuthorizationCode(c, client, req, scopes, session, user)
+}
+
+// validateAuthorizeRequest checks the authorization request against the client's registration.
+// It responds to the user agent itself and returns a nil client when the request is invalid.
+func (h *OIDCHandler) validateAuthorizeRequest(c echo.Context, req *AuthorizeRequest) (*sqlc.Client, []string, error) {
+	// Errors about the client or redirect URI must not be redirected,
+	// otherwise the endpoint could be used as an open redirector
+	if req.ClientID == "" {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"client_id is required",
+		)
+	}
+
+	if req.Request != "" && req.RequestURI != "" {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"request and request_uri cannot be used together",
+		)
+	}
+
+	if req.isPushed() {
+		if err := h.resolvePushedRequest(c.Request().Context(), req); err != nil {
+			return nil, nil, respondWithOAuthError(c, err)
+		}
+	}
+
+	client, err := h.store.GetClientWithOIDCSettings(c.Request().Context(), req.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, utils.RespondWithOAuthError(
+				c,
+				utils.StatusCodeBadRequest,
+				utils.OAuthErrorInvalidRequest,
+				"Unknown client or OIDC is not enabled for this client",
+			)
+		}
+		return nil, nil, utils.RespondWithInternalError(c, "Could not retrieve client", err)
+	}
+
+	// The request object is verified with the client's keys, so it is read once the client is known
+	if req.Request != "" || (req.RequestURI != "" && !req.isPushed()) {
+		if err := h.resolveRequestObject(c.Request().Context(), client, req); err != nil {
+			return nil, nil, respondWithOAuthError(c, err)
+		}
+	}
+
+	if client.RequirePushedAuthorizationRequests && !req.isPushed() {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"This client must push its authorization requests to the pushed authorization request endpoint",
+		)
+	}
+	if client.RequireSignedRequestObject && !req.fromRequestObject {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"This client must send its authorization requests as signed request objects",
+		)
+	}
+
+	if req.RedirectURI == "" {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"redirect_uri is required",
+		)
+	}
+	if !redirectURIRegistered(client, req.RedirectURI) {
+		return nil, nil, utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"redirect_uri is not registered for this client",
+		)
+	}
+
+	// From here on errors are reported back to the client through the redirect URI
+	scopes, err := checkAuthorizeParameters(client, req)
+	if err != nil {
+		var oe *oauthError
+		if !errors.As(err, &oe) {
+			return nil, nil, utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not validate the authorization request", req.State)
+		}
+		return nil, nil, utils.RedirectWithOAuthError(c, req.RedirectURI, oe.code, oe.description, req.State)
+	}
+
+	return &client, scopes, nil
+}
+
+// checkAuthorizeParameters checks the parameters of an authorization request other than
+// the client and redirect URI, returning the requested scopes. It is shared with the
+// pushed authorization request endpoint, which reports the errors directly to the client.
+func checkAuthorizeParameters(client sqlc.Client, req *AuthorizeRequest) ([]string, error) {
+	invalid := func(code utils.OAuthErrorCode, description string) error {
+		return newOAuthError(utils.StatusCodeBadRequest, code, description)
+	}
+
+	if req.ResponseType == "" {
+		return nil, invalid(utils.OAuthErrorInvalidRequest, "response_type is required")
+	}
+	if req.ResponseType != "code" || !slices.Contains(clientResponseTypes(client), req.ResponseType) {
+		return nil, invalid(utils.OAuthErrorUnsupportedResponseType, "Only the code response type is allowed for this client")
+	}
+
+	scopes := utils.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		return nil, invalid(utils.OAuthErrorInvalidScope, "scope is required")
+	}
+	if denied := unsupportedScopes(client, scopes); len(denied) > 0 {
+		return nil, invalid(utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope not allowed for this client: %s", strings.Join(denied, " ")))
+	}
+
+	if req.CodeChallengeMethod != "" && req.CodeChallenge == "" {
+		return nil, invalid(utils.OAuthErrorInvalidRequest, "code_challenge is required when code_challenge_method is set")
+	}
+	if req.CodeChallenge != "" {
+		if req.CodeChallengeMethod == "" {
+			// RFC 7636 section 4.3: defaults to plain when not present
+			req.CodeChallengeMethod = utils.CodeChallengeMethodPlain
+		}
+		if req.CodeChallengeMethod != utils.CodeChallengeMethodS256 && req.CodeChallengeMethod != utils.CodeChallengeMethodPlain {
+			return nil, invalid(utils.OAuthErrorInvalidRequest, "Unsupported code_challenge_method")
+		}
+	} else if client.IsPublic {
+		// Public clients cannot keep a secret, PKCE is what binds the code to them
+		return nil, invalid(utils.OAuthErrorInvalidRequest, "code_challenge is required for public clients")
+	}
+
+	prompts := strings.Fields(req.Prompt)
+	for _, prompt := range prompts {
+		if !slices.Contains(supportedPrompts, prompt) {
+			return nil, invalid(utils.OAuthErrorInvalidRequest, fmt.Sprintf("Unsupported prompt value: %s", prompt))
+		}
+	}
+	if slices.Contains(prompts, promptNone) && len(prompts) > 1 {
+		return nil, invalid(utils.OAuthErrorInvalidRequest, "prompt=none cannot be combined with other values")
+	}
+	if req.MaxAge != "" {
+		if maxAge, err := strconv.Atoi(req.MaxAge); err != nil || maxAge < 0 {
+			return nil, invalid(utils.OAuthErrorInvalidRequest, "max_age must be a non-negative number of seconds")
+		}
+	}
+
+	return scopes, nil
+}
+
+// authorizingUser loads the signed-in user, who must still be active.
+// It responds with an error redirect and returns nil otherwise.
+func (h *OIDCHandler) authorizingUser(c echo.Context, req *AuthorizeRequest, session sqlc.Session) (*sqlc.User, error) {
+	user, err := h.store.GetUserById(c.Request().Context(), session.UserID)
+	if err != nil {
+		return nil, utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not get user information", req.State)
+	}
+	if !user.IsActive {
+		return nil, utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorAccessDenied, "User account is disabled", req.State)
+	}
+	return &user, nil
+}
+
+// issueAuthorizationCode stores an authorization code for the user and returns it to the client
+func (h *OIDCHandler) issueAuthorizationCode(c echo.Context, client *sqlc.Client, req *AuthorizeRequest, scopes []string, session sqlc.Session, user *sqlc.User) error {
+	code, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not generate authorization code", req.State)
+	}
+
+	_, err = h.store.CreateOIDCAuthCode(c.Request().Context(), sqlc.CreateOIDCAuthCodeParams{
+		Code:        code,
+		ClientID:    client.ClientID,
+		UserID:      user.ID,
+		RedirectUri: req.RedirectURI,
+		ExpiresAt:   time.Now().Add(h.config.OIDC.AuthCodeExpiry),
+		Scopes:      scopes,
+		CodeChallenge: sql.NullString{
+			String: req.CodeChallenge,
+			Valid:  req.CodeChallenge != "",
+		},
+		CodeChallengeMethod: sql.NullString{
+			String: req.CodeChallengeMethod,
+			Valid:  req.CodeChallengeMethod != "",
+		},
+		Nonce: sql.NullString{
+			String: req.Nonce,
+			Valid:  req.Nonce != "",
+		},
+		AuthTime:  session.CreatedAt,
+		SessionID: sql.NullInt32{Int32: session.ID, Valid: true},
+		Acr:       sql.NullString{String: sessionACR(session.Amr), Valid: true},
+		Amr:       session.Amr,
+	})
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not store authorization code", req.State)
+	}
+
+	// A pushed request is used once; a reload of the page starts over at the client
+	if req.isPushed() {
+		if err := h.store.DeleteOIDCPushedAuthorizationRequest(c.Request().Context(), req.RequestURI); err != nil {
+			log.Printf("Failed to delete pushed authorization request: %v", err)
+		}
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return c.Redirect(http.StatusFound, utils.AppendQuery(req.RedirectURI, params))
+}
+
+// redirectToLogin sends the user agent to the CentralAuth login page.
+// The login page returns to the authorization endpoint with the same parameters afterwards,
+// except for those demanding a fresh sign-in, which the new session satisfies.
+func (h *OIDCHandler) redirectToLogin(c echo.Context, req *AuthorizeRequest) error {
+	next := *req
+	next.Prompt = strings.Join(slices.DeleteFunc(strings.Fields(req.Prompt), func(prompt string) bool {
+		return prompt == promptLogin
+	}), " ")
+	if maxAge, ok := req.maxAge(); ok && maxAge == 0 {
+		// max_age=0 is equivalent to prompt=login
+		next.MaxAge = ""
+	}
+	if req.RequestURI != "" || req.Request != "" {
+		// The parameters of a pushed request or a request object cannot be changed in the URL,
+		// so the adjusted request is pushed, which also gives the user a full lifetime to sign in
+		var err error
+		if next, err = h.repushAuthorizeRequest(c.Request().Context(), next); err != nil {
+			return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not prepare the login page", req.State)
+		}
+	}
+	authorizeURL := fmt.Sprintf("%s://%s%s?%s", c.Scheme(), c.Request().Host, pathAuthorize, next.Values().Encode())
+
+	params := url.Values{}
+	params.Set("redirect", authorizeURL)
+	if req.LoginHint != "" {
+		params.Set("login_hint", req.LoginHint)
+	}
+	return c.Redirect(http.StatusFound, utils.AppendQuery(h.config.ClientURL+"/login", params))
+}
+
+// isPushed reports whether the request refers to parameters pushed to /oauth2/par
+func (r *AuthorizeRequest) isPushed() bool {
+	return strings.HasPrefix(r.RequestURI, requestURIPrefix)
+}
+
+// hasPrompt reports whether the request's prompt parameter includes the value
+func (r *AuthorizeRequest) hasPrompt(prompt string) bool {
+	return slices.Contains(strings.Fields(r.Prompt), prompt)
+}
+
+// maxAge returns the maximum authentication age the request allows, if it sets one
+func (r *AuthorizeRequest) maxAge() (time.Duration, bool) {
+	seconds, err := strconv.Atoi(r.MaxAge)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// reauthenticationRequired reports whether the user has to sign in again, either
+// because the request asks for it or because the session is older than max_age
+func (r *AuthorizeRequest) reauthenticationRequired(session sqlc.Session) bool {
+	if r.hasPrompt(promptLogin) {
+		return true
+	}
+	maxAge, ok := r.maxAge()
+	return ok && time.Since(session.CreatedAt) > maxAge
+}
@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// Client authentication methods accepted by the token endpoint (OpenID Connect Core section 9)
+const (
+	authMethodClientSecretBasic = utils.AuthMethodClientSecretBasic
+	authMethodClientSecretPost  = utils.AuthMethodClientSecretPost
+	authMethodClientSecretJWT   = utils.AuthMethodClientSecretJWT
+	authMethodPrivateKeyJWT     = utils.AuthMethodPrivateKeyJWT
+	authMethodNone              = utils.AuthMethodNone
+)
+
+// supportedAuthMethods lists the client authentication methods in discovery order
+var supportedAuthMethods = []string{
+	authMethodClientSecretBasic,
+	authMethodClientSecretPost,
+	authMethodClientSecretJWT,
+	authMethodPrivateKeyJWT,
+	authMethodNone,
+}
+
+// clientAssertionTypeJWTBearer is the client_assertion_type of JWT client authentication (RFC 7523 section 2.2)
+const clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+const (
+	// maxClientAssertionLifetime bounds how far ahead a client assertion may expire,
+	// and with it how long its jti has to be remembered
+	maxClientAssertionLifetime = time.Hour
+	// clientAssertionLeeway tolerates clock skew between the client and the provider
+	clientAssertionLeeway = 30 * time.Second
+)
+
+// clientCredentials holds the credentials a client presented to a token endpoint
+type clientCredentials struct {
+	clientID     string
+	clientSecret string
+	assertion    string
+	basicAuth    bool
+}
+
+// readClientCredentials extracts client credentials from the Authorization header
+// (client_secret_basic) or from the form body (client_secret_post, or a JWT assertion
+// for client_secret_jwt and private_key_jwt)
+func readClientCredentials(c echo.Context, form ClientAuthentication) (clientCredentials, error) {
+	multipleMethods := newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Client credentials must not be sent using more than one method")
+
+	username, password, hasBasic := c.Request().BasicAuth()
+	if hasBasic {
+		if form.ClientSecret != "" || form.ClientAssertion != "" {
+			return clientCredentials{}, multipleMethods
+		}
+
+		// RFC 6749 section 2.3.1: both values are form-urlencoded before being base64 encoded
+		clientID, err := url.QueryUnescape(username)
+		if err != nil {
+			return clientCredentials{}, &oauthError{status: utils.StatusCodeUnauthorized, code: utils.OAuthErrorInvalidClient, description: "Malformed client credentials", basicAuth: true}
+		}
+		clientSecret, err := url.QueryUnescape(password)
+		if err != nil {
+			return clientCredentials{}, &oauthError{status: utils.StatusCodeUnauthorized, code: utils.OAuthErrorInvalidClient, description: "Malformed client credentials", basicAuth: true}
+		}
+		if form.ClientID != "" && form.ClientID != clientID {
+			return clientCredentials{}, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_id does not match the authenticated client")
+		}
+		return clientCredentials{clientID: clientID, clientSecret: clientSecret, basicAuth: true}, nil
+	}
+
+	if form.ClientAssertion == "" && form.ClientAssertionType == "" {
+		return clientCredentials{clientID: form.ClientID, clientSecret: form.ClientSecret}, nil
+	}
+
+	if form.ClientSecret != "" {
+		return clientCredentials{}, multipleMethods
+	}
+	if form.ClientAssertionType != clientAssertionTypeJWTBearer {
+		return clientCredentials{}, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_assertion_type must be "+clientAssertionTypeJWTBearer)
+	}
+	if form.ClientAssertion == "" {
+		return clientCredentials{}, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_assertion is required")
+	}
+
+	// client_id is optional with an assertion, whose sub identifies the client (RFC 7523 section 3)
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(form.ClientAssertion, &claims); err != nil {
+		return clientCredentials{}, newOAuthError(utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidClient, "Malformed client assertion")
+	}
+	if form.ClientID != "" && form.ClientID != claims.Subject {
+		return clientCredentials{}, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_id does not match the client assertion")
+	}
+	return clientCredentials{clientID: claims.Subject, assertion: form.ClientAssertion}, nil
+}
+
+// authenticateClient identifies and authenticates the client calling a token endpoint.
+// Public clients are identified by their client_id alone. Confidential clients present
+// their client secret or, when registered for a JWT method, a signed assertion.
+func (h *OIDCHandler) authenticateClient(c echo.Context, form ClientAuthentication) (sqlc.Client, error) {
+	creds, err := readClientCredentials(c, form)
+	if err != nil {
+		return sqlc.Client{}, err
+	}
+
+	invalidClient := &oauthError{
+		status:      utils.StatusCodeUnauthorized,
+		code:        utils.OAuthErrorInvalidClient,
+		description: "Client authentication failed",
+		basicAuth:   creds.basicAuth,
+	}
+
+	if creds.clientID == "" {
+		return sqlc.Client{}, invalidClient
+	}
+
+	client, err := h.store.GetClientWithOIDCSettings(c.Request().Context(), creds.clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.Client{}, invalidClient
+		}
+		return sqlc.Client{}, err
+	}
+
+	if client.IsPublic {
+		return client, nil
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case authMethodClientSecretJWT, authMethodPrivateKeyJWT:
+		// A client registered for a JWT method never authenticates with its secret
+		if creds.assertion == "" {
+			return sqlc.Client{}, invalidClient
+		}
+		if err := h.verifyClientAssertion(c, client, creds.assertion); err != nil {
+			return sqlc.Client{}, err
+		}
+		return client, nil
+	}
+
+	// client_secret_basic and client_secret_post carry the same secret and are both accepted
+	if creds.clientSecret == "" {
+		return sqlc.Client{}, invalidClient
+	}
+	ok, err := h.verifyClientSecret(c.Request().Context(), client.ClientID, creds.clientSecret)
+	if err != nil {
+		return sqlc.Client{}, err
+	}
+	if !ok {
+		return sqlc.Client{}, invalidClient
+	}
+
+	return client, nil
+}
+
+// verifyClientAssertion checks a client assertion (RFC 7523 section 3): it must be signed
+// by the client, name the client as iss and sub and this provider as aud, expire soon,
+// and carry a jti that was not used before.
+func (h *OIDCHandler) verifyClientAssertion(c echo.Context, client sqlc.Client, assertion string) error {
+	ctx := c.Request().Context()
+	invalidAssertion := func(description string) error {
+		return newOAuthError(utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidClient, description)
+	}
+
+	algs := privateKeyJWTAlgs
+	if client.TokenEndpointAuthMethod == authMethodClientSecretJWT {
+		algs = clientSecretJWTAlgs
+	}
+
+	var claims jwt.RegisteredClaims
+	err := h.parseClientJWT(ctx, client, assertion, algs, &claims,
+		jwt.WithIssuer(client.ClientID),
+		jwt.WithSubject(client.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clientAssertionLeeway),
+	)
+	if err != nil {
+		if errors.Is(err, errInvalidClientJWT) {
+			return invalidAssertion("Invalid client assertion: " + strings.TrimPrefix(err.Error(), errInvalidClientJWT.Error()+": "))
+		}
+		return err
+	}
+
+	// The audience is this provider: its issuer, its token endpoint or the endpoint being called
+	audiences := []string{
+		h.config.OIDC.Issuer,
+		h.config.OIDC.Issuer + pathToken,
+		h.config.OIDC.Issuer + c.Request().URL.Path,
+	}
+	if !slices.ContainsFunc(claims.Audience, func(aud string) bool { return slices.Contains(audiences, aud) }) {
+		return invalidAssertion("The client assertion is not intended for this provider")
+	}
+	if claims.ExpiresAt.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return invalidAssertion("The client assertion expires too far in the future")
+	}
+	if claims.ID == "" || len(claims.ID) > 255 {
+		return invalidAssertion("The client assertion must have a jti of at most 255 characters")
+	}
+
+	recorded, err := h.store.RecordClientAssertionJTI(ctx, sqlc.RecordClientAssertionJTIParams{
+		ClientID:  client.ClientID,
+		Jti:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+	if err != nil {
+		return err
+	}
+	if recorded == 0 {
+		return invalidAssertion("The client assertion has already been used")
+	}
+
+	if err := h.store.DeleteExpiredClientAssertionJTIs(ctx); err != nil {
+		log.Printf("Failed to delete expired client assertion identifiers: %v", err)
+	}
+	return nil
+}
+
+// verifyClientSecret checks a presented secret against the valid secrets of the client,
+// of which there are two while the client rotates its secret
+func (h *OIDCHandler) verifyClientSecret(ctx context.Context, clientID, secret string) (bool, error) {
+	secrets, err := h.store.ListValidClientSecrets(ctx, clientID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, stored := range secrets {
+		if !utils.VerifyClientSecret(stored.SecretHash, secret) {
+			continue
+		}
+		// Only shown to administrators deciding when a rotated secret can be revoked
+		if err := h.store.TouchClientSecret(ctx, stored.ID); err != nil {
+			log.Printf("Failed to record the use of secret %d of client %s: %v", stored.ID, clientID, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
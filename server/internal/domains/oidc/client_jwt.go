@@ -0,0 +1,242 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms clients may sign JWTs with: HMAC with their secret for client_secret_jwt,
+// or an asymmetric algorithm with one of their registered keys for private_key_jwt
+var (
+	clientSecretJWTAlgs = []string{"HS256", "HS384", "HS512"}
+	privateKeyJWTAlgs   = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+const (
+	// clientJWKSCacheTTL is how long keys fetched from a jwks_uri are used before they are fetched again
+	clientJWKSCacheTTL = 5 * time.Minute
+	// clientJWKSRefetchInterval limits how often an unknown kid triggers an early fetch
+	clientJWKSRefetchInterval = 30 * time.Second
+	// clientJWKSMaxBytes bounds the size of a key set read from a jwks_uri
+	clientJWKSMaxBytes = 1 << 20
+)
+
+// errInvalidClientJWT is wrapped by the errors of parseClientJWT the client is responsible for
+var errInvalidClientJWT = errors.New("invalid JWT")
+
+// errClientJWKSUnavailable is returned when the keys at a jwks_uri cannot be fetched. The cause
+// is only logged: reporting it to the client would reveal what the server can reach.
+var errClientJWKSUnavailable = errors.New("could not fetch the keys from the jwks_uri")
+
+// invalidClientJWT returns an error wrapping errInvalidClientJWT
+func invalidClientJWT(reason error) error {
+	return fmt.Errorf("%w: %w", errInvalidClientJWT, reason)
+}
+
+// cachedClientJWKS holds the keys last fetched from a jwks_uri
+type cachedClientJWKS struct {
+	keys      []utils.JWK
+	fetchedAt time.Time
+}
+
+var (
+	clientJWKSMu    sync.Mutex
+	clientJWKSCache = make(map[string]cachedClientJWKS)
+
+	clientJWKSHTTPClient = &http.Client{
+		Timeout: 5 * time.Second,
+		// The key set must be served at the registered URI itself
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// fetchClientJWKS returns the keys published at a client's jwks_uri. The keys are cached;
+// refresh fetches them again early, as when a JWT names a kid the cached keys do not have
+// because the client rotated its keys. When a fetch fails the previous keys are used.
+func fetchClientJWKS(ctx context.Context, uri string, refresh bool) ([]utils.JWK, error) {
+	clientJWKSMu.Lock()
+	cached, ok := clientJWKSCache[uri]
+	clientJWKSMu.Unlock()
+
+	age := time.Since(cached.fetchedAt)
+	if ok && age < clientJWKSCacheTTL && (!refresh || age < clientJWKSRefetchInterval) {
+		return cached.keys, nil
+	}
+
+	keys, err := downloadJWKS(ctx, uri)
+	if err != nil {
+		if ok {
+			log.Printf("Failed to refresh the client JWKS at %s, using the cached keys: %v", uri, err)
+			return cached.keys, nil
+		}
+		log.Printf("Failed to fetch the client JWKS at %s: %v", uri, err)
+		return nil, errClientJWKSUnavailable
+	}
+
+	clientJWKSMu.Lock()
+	clientJWKSCache[uri] = cachedClientJWKS{keys: keys, fetchedAt: time.Now()}
+	clientJWKSMu.Unlock()
+	return keys, nil
+}
+
+// downloadJWKS fetches a JSON Web Key Set
+func downloadJWKS(ctx context.Context, uri string) ([]utils.JWK, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := clientJWKSHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the JWKS returned status %d", resp.StatusCode)
+	}
+
+	var jwks utils.JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, clientJWKSMaxBytes)).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	return jwks.Keys, nil
+}
+
+// clientPublicKeys returns the keys a client registered, inline or through its jwks_uri
+func clientPublicKeys(ctx context.Context, client sqlc.Client, refresh bool) ([]utils.JWK, error) {
+	if client.Jwks.Valid {
+		var jwks utils.JWKS
+		if err := json.Unmarshal([]byte(client.Jwks.String), &jwks); err != nil {
+			return nil, fmt.Errorf("invalid registered JWKS: %w", err)
+		}
+		return jwks.Keys, nil
+	}
+	if client.JwksUri.Valid {
+		return fetchClientJWKS(ctx, client.JwksUri.String, refresh)
+	}
+	return nil, nil
+}
+
+// keyTypeForAlg returns the JWK key type that signs with an asymmetric algorithm
+func keyTypeForAlg(alg string) string {
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		return "RSA"
+	case strings.HasPrefix(alg, "ES"):
+		return "EC"
+	case alg == "EdDSA":
+		return "OKP"
+	default:
+		return ""
+	}
+}
+
+// parseClientJWT verifies a JWT signed by a client and decodes it into claims. HMAC
+// algorithms are verified with the client's secrets, asymmetric ones with its registered
+// public keys. Errors caused by the token or the client's keys wrap errInvalidClientJWT.
+func (h *OIDCHandler) parseClientJWT(ctx context.Context, client sqlc.Client, tokenString string, algs []string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return invalidClientJWT(err)
+	}
+	alg, _ := unverified.Header["alg"].(string)
+	if !slices.Contains(algs, alg) {
+		return invalidClientJWT(fmt.Errorf("signing algorithm %q is not accepted", alg))
+	}
+	notSigned := invalidClientJWT(errors.New("not signed with a key of the client"))
+	kid, _ := unverified.Header["kid"].(string)
+
+	parser := jwt.NewParser(append([]jwt.ParserOption{jwt.WithValidMethods([]string{alg})}, opts...)...)
+	// verify reports whether key signed the token, the signature is checked before the claims
+	verify := func(key any) (bool, error) {
+		_, err := parser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) {
+			return key, nil
+		})
+		if errors.Is(err, jwt.ErrTokenSignatureInvalid) || errors.Is(err, jwt.ErrTokenUnverifiable) {
+			return false, nil
+		}
+		if err != nil {
+			return false, invalidClientJWT(err)
+		}
+		return true, nil
+	}
+
+	if strings.HasPrefix(alg, "HS") {
+		secrets, err := h.store.ListValidClientSecrets(ctx, client.ClientID)
+		if err != nil {
+			return err
+		}
+		for _, stored := range secrets {
+			// Secrets issued before the client switched to client_secret_jwt have no copy
+			if stored.SecretEncrypted == nil {
+				continue
+			}
+			secret, err := utils.Decrypt(utils.ClientSecretEncryptionKey(h.config), stored.SecretEncrypted)
+			if err != nil {
+				return err
+			}
+			ok, err := verify(secret)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := h.store.TouchClientSecret(ctx, stored.ID); err != nil {
+					log.Printf("Failed to record the use of secret %d of client %s: %v", stored.ID, client.ClientID, err)
+				}
+				return nil
+			}
+		}
+		return notSigned
+	}
+
+	for _, refresh := range []bool{false, true} {
+		keys, err := clientPublicKeys(ctx, client, refresh)
+		if err != nil {
+			return invalidClientJWT(err)
+		}
+
+		found := false
+		for _, jwk := range keys {
+			if jwk.Kty != keyTypeForAlg(alg) || (kid != "" && jwk.Kid != kid) ||
+				(jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != alg) {
+				continue
+			}
+			found = true
+
+			key, err := jwk.PublicKey()
+			if err != nil {
+				continue
+			}
+			ok, err := verify(key)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+
+		// Keys from a jwks_uri are fetched again once when none of them matches
+		if found || !client.JwksUri.Valid || client.Jwks.Valid {
+			break
+		}
+	}
+	return notSigned
+}
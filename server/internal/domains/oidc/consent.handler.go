@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"slices"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Decisions the consent page can post
+const (
+	consentDecisionAllow = "allow"
+	consentDecisionDeny  = "deny"
+)
+
+const (
+	// pathConsent is where the consent page posts the user's decision
+	pathConsent = "/oauth2/consent"
+	// consentCSRFCookie holds the token the consent form has to echo back,
+	// so that another site cannot post a decision on the user's behalf
+	consentCSRFCookie = "consent_csrf"
+	// consentCSRFMaxAge is how long the user has to answer the consent page, in seconds
+	consentCSRFMaxAge = 10 * 60
+)
+
+// scopeDescriptions explain to the user what a client gets access to with each scope
+var scopeDescriptions = map[string]string{
+	scopeOpenID:  "Sign you in with your CentralAuth account",
+	scopeProfile: "See your name and username",
+	scopeEmail:   "See your email address",
+	scopePhone:   "See your phone number",
+}
+
+// consentScope is a requested scope as listed on the consent page
+type consentScope struct {
+	Name        string
+	Description string
+}
+
+// consentPage is the data rendered by the consent template
+type consentPage struct {
+	ClientName    string
+	ClientWebsite string
+	Scopes        []consentScope
+	Action        string
+	Params        map[string]string
+	CSRFToken     string
+}
+
+// describeScopes lists the requested scopes the way they are shown to the user
+func describeScopes(scopes []string) []consentScope {
+	described := make([]consentScope, 0, len(scopes))
+	for _, scope := range scopes {
+		description, ok := scopeDescriptions[scope]
+		if !ok {
+			description = "Access " + scope
+		}
+		described = append(described, consentScope{Name: scope, Description: description})
+	}
+	return described
+}
+
+// hasConsent reports whether the user already granted the client all of the scopes
+func (h *OIDCHandler) hasConsent(ctx context.Context, clientID string, userID int32, scopes []string) (bool, error) {
+	consent, err := h.store.GetOIDCConsent(ctx, sqlc.GetOIDCConsentParams{
+		ClientID: clientID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// renderConsent asks the user whether the client may access the requested scopes.
+// The form posts the authorization request back to the consent endpoint along with the decision.
+func (h *OIDCHandler) renderConsent(c echo.Context, client *sqlc.Client, req *AuthorizeRequest, scopes []string) error {
+	csrfToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not prepare the consent page", req.State)
+	}
+	if err := utils.SetCookie(c, consentCSRFCookie, csrfToken, consentCSRFMaxAge); err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not prepare the consent page", req.State)
+	}
+
+	page := consentPage{
+		ClientName:    client.Name,
+		ClientWebsite: client.Website.String,
+		Scopes:        describeScopes(scopes),
+		Action:        pathConsent,
+		Params:        make(map[string]string),
+		CSRFToken:     csrfToken,
+	}
+	next := *req
+	if req.Request != "" || (req.RequestURI != "" && !req.isPushed()) {
+		// The request object would be verified again when the decision is posted
+		if next, err = h.repushAuthorizeRequest(c.Request().Context(), next); err != nil {
+			return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not prepare the consent page", req.State)
+		}
+	}
+	values := next.Values()
+	for key := range values {
+		page.Params[key] = values.Get(key)
+	}
+
+	// The decision buttons must not be clickable from inside another site's frame
+	c.Response().Header().Set("X-Frame-Options", "DENY")
+	c.Response().Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	return renderPage(c, "consent.html", page)
+}
+
+// Consent handles the decision posted from the consent page (POST /oauth2/consent).
+// On approval the grant is recorded and the authorization request continues.
+func (h *OIDCHandler) Consent(c echo.Context) error {
+	req := new(ConsentRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse consent request",
+		)
+	}
+
+	// The authorization request is validated again, the form could have been altered
+	client, scopes, err := h.validateAuthorizeRequest(c, &req.AuthorizeRequest)
+	if client == nil {
+		return err
+	}
+
+	csrfToken, _ := utils.GetCookie(c, consentCSRFCookie)
+	if csrfToken == "" || subtle.ConstantTimeCompare([]byte(csrfToken), []byte(req.CSRFToken)) != 1 {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"The consent form has expired, please try again",
+		)
+	}
+	utils.DeleteCookie(c, consentCSRFCookie)
+
+	session, ok, err := h.currentSession(c)
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not check the user session", req.State)
+	}
+	if !ok {
+		return h.redirectToLogin(c, &req.AuthorizeRequest)
+	}
+
+	user, err := h.authorizingUser(c, &req.AuthorizeRequest, session)
+	if user == nil {
+		return err
+	}
+
+	switch req.Decision {
+	case consentDecisionAllow:
+	case consentDecisionDeny:
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorAccessDenied, "The user denied the request", req.State)
+	default:
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorInvalidRequest, "Unknown consent decision", req.State)
+	}
+
+	_, err = h.store.UpsertOIDCConsent(c.Request().Context(), sqlc.UpsertOIDCConsentParams{
+		ClientID: client.ClientID,
+		UserID:   user.ID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return utils.RedirectWithOAuthError(c, req.RedirectURI, utils.OAuthErrorServerError, "Could not store the user's consent", req.State)
+	}
+
+	return h.issueAuthorizationCode(c, client, &req.AuthorizeRequest, scopes, session, user)
+}
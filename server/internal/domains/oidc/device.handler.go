@@ -0,0 +1,460 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// Statuses of a device authorization request
+const (
+	deviceCodeStatusPending  = "pending"
+	deviceCodeStatusApproved = "approved"
+	deviceCodeStatusDenied   = "denied"
+	deviceCodeStatusConsumed = "consumed"
+)
+
+const (
+	// userCodeAlphabet has no vowels, so user codes cannot spell words, and no characters
+	// that are easily confused with each other (RFC 8628 section 6.1)
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	// userCodeLength gives 20^8 possible codes, shown to the user as XXXX-XXXX
+	userCodeLength = 8
+	// userCodeAttempts is how often a new user code is drawn when it collides with an existing one
+	userCodeAttempts = 3
+	// deviceSlowDownIncrement is added to the polling interval of a device polling too often (RFC 8628 section 3.5)
+	deviceSlowDownIncrement = 5 * time.Second
+	// deviceCSRFCookie holds the token the verification form has to echo back
+	deviceCSRFCookie = "device_csrf"
+	// deviceCSRFMaxAge is how long the user has to answer the verification page, in seconds
+	deviceCSRFMaxAge = 10 * 60
+)
+
+// devicePage is the data rendered by the device verification template. Without a client
+// the user is asked for the code, with one to approve the request, and with a result the
+// user is told what happened.
+type devicePage struct {
+	Action        string
+	UserCode      string
+	CSRFToken     string
+	Error         string
+	ClientName    string
+	ClientWebsite string
+	Scopes        []consentScope
+	Result        string
+}
+
+// generateUserCode draws a random user code from userCodeAlphabet
+func generateUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating user code: %w", err)
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode turns a user code as typed by the user into its stored form.
+// Case, spaces and dashes are ignored; it reports false for anything else that is not part of a code.
+func normalizeUserCode(input string) (string, bool) {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(input) {
+		switch {
+		case r == '-' || r == ' ':
+		case strings.ContainsRune(userCodeAlphabet, r):
+			b.WriteRune(r)
+		default:
+			return "", false
+		}
+	}
+	if b.Len() != userCodeLength {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// formatUserCode inserts a dash in the middle of a user code to make it easier to read
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// DeviceAuthorization handles the device authorization endpoint (POST /oauth2/device_authorization).
+// Devices without a browser receive a device code to poll the token endpoint with, and a
+// user code the user enters on the verification page from another device (RFC 8628 section 3.1).
+func (h *OIDCHandler) DeviceAuthorization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := new(DeviceAuthorizationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse device authorization request",
+		)
+	}
+
+	client, err := h.authenticateClient(c, req.ClientAuthentication)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	if !slices.Contains(clientGrantTypes(client), grantTypeDeviceCode) {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorUnauthorizedClient, "Client is not allowed to use the device authorization grant")
+	}
+
+	scopes := utils.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, "scope is required")
+	}
+	if denied := unsupportedScopes(client, scopes); len(denied) > 0 {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope not allowed for this client: %s", strings.Join(denied, " ")))
+	}
+
+	// Requests that expired a while ago are removed; recently expired ones are kept,
+	// so devices still polling them are told they expired
+	if err := h.store.DeleteExpiredOIDCDeviceCodes(ctx); err != nil {
+		log.Printf("Failed to delete expired device codes: %v", err)
+	}
+
+	deviceCode, err := h.createDeviceCode(ctx, client, scopes)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	userCode := formatUserCode(deviceCode.UserCode)
+	verificationURI := h.config.OIDC.Issuer + pathDeviceVerification
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeSuccess), DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode.DeviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?" + url.Values{"user_code": {userCode}}.Encode(),
+		ExpiresIn:               int64(time.Until(deviceCode.ExpiresAt).Seconds()),
+		Interval:                int64(deviceCode.PollInterval),
+	})
+}
+
+// createDeviceCode stores a new device authorization request, drawing another user
+// code when the first one is taken by a live request. A user code held by an expired
+// request is released, so it can be drawn again.
+func (h *OIDCHandler) createDeviceCode(ctx context.Context, client sqlc.Client, scopes []string) (sqlc.OidcDeviceCode, error) {
+	deviceCode, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return sqlc.OidcDeviceCode{}, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		userCode, err := generateUserCode()
+		if err != nil {
+			return sqlc.OidcDeviceCode{}, err
+		}
+
+		created, err := h.store.CreateOIDCDeviceCode(ctx, sqlc.CreateOIDCDeviceCodeParams{
+			DeviceCode:   deviceCode,
+			UserCode:     userCode,
+			ClientID:     client.ClientID,
+			Scopes:       scopes,
+			PollInterval: int32(h.config.OIDC.DevicePollInterval / time.Second),
+			ExpiresAt:    time.Now().Add(h.config.OIDC.DeviceCodeExpiry),
+		})
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && attempt < userCodeAttempts {
+			if _, err := h.store.ReleaseExpiredOIDCDeviceUserCode(ctx, userCode); err != nil {
+				return sqlc.OidcDeviceCode{}, err
+			}
+			continue
+		}
+		return created, err
+	}
+}
+
+// deviceCodeGrant exchanges the device code of an approved request for tokens (RFC 8628 section 3.4).
+// Until the user answers, the device is told to keep polling at the agreed interval.
+func (h *OIDCHandler) deviceCodeGrant(c echo.Context, client sqlc.Client, req *TokenRequest) error {
+	ctx := c.Request().Context()
+
+	if req.DeviceCode == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "device_code is required")
+	}
+
+	deviceCode, err := h.store.GetOIDCDeviceCodeByDeviceCode(ctx, req.DeviceCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Device code is invalid")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	if deviceCode.ClientID != client.ClientID {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Device code was issued to another client")
+	}
+
+	if deviceCode.ExpiresAt.Before(time.Now()) {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorExpiredToken, "Device code has expired, start a new device authorization")
+	}
+
+	switch deviceCode.Status {
+	case deviceCodeStatusPending:
+		return h.pollPendingDeviceCode(c, deviceCode)
+	case deviceCodeStatusDenied:
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorAccessDenied, "The user denied the request")
+	case deviceCodeStatusConsumed:
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Device code has already been used")
+	}
+
+	// Consuming the code and reading it happen in a single statement,
+	// so two concurrent requests cannot both exchange it
+	deviceCode, err = h.store.ConsumeOIDCDeviceCode(ctx, deviceCode.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Device code has already been used")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	user, err := h.store.GetUserById(ctx, deviceCode.UserID.Int32)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if !user.IsActive {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "User account is disabled")
+	}
+
+	res, err := h.issueTokens(ctx, tokenGrant{
+		client:    client,
+		user:      &user,
+		scopes:    deviceCode.Scopes,
+		authTime:  deviceCode.AuthTime.Time,
+		acr:       deviceCode.Acr.String,
+		amr:       deviceCode.Amr,
+		sessionID: deviceCode.SessionID,
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	return respondWithToken(c, res)
+}
+
+// pollPendingDeviceCode answers a device polling for a request the user has not answered yet.
+// A device polling before its interval has passed must wait longer from then on.
+func (h *OIDCHandler) pollPendingDeviceCode(c echo.Context, deviceCode sqlc.OidcDeviceCode) error {
+	interval := time.Duration(deviceCode.PollInterval) * time.Second
+	tooFast := deviceCode.LastPolledAt.Valid && time.Since(deviceCode.LastPolledAt.Time) < interval
+	if tooFast {
+		interval += deviceSlowDownIncrement
+	}
+
+	err := h.store.UpdateOIDCDeviceCodePoll(c.Request().Context(), sqlc.UpdateOIDCDeviceCodePollParams{
+		ID:           deviceCode.ID,
+		PollInterval: int32(interval / time.Second),
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	if tooFast {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorSlowDown, fmt.Sprintf("Polling too often, wait %d seconds between requests", int(interval/time.Second)))
+	}
+	return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorAuthorizationPending, "The user has not answered the request yet")
+}
+
+// DeviceVerification serves the page where a signed-in user enters the code shown by a
+// device and approves or denies its request (GET and POST /oauth2/device). The request
+// is shown to the user before it can be approved, so a code sent by someone else is noticed.
+func (h *OIDCHandler) DeviceVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := new(DeviceVerificationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse device verification request",
+		)
+	}
+
+	session, ok, err := h.currentSession(c)
+	if err != nil {
+		return utils.RespondWithInternalError(c, "Could not check the user session", err)
+	}
+	if !ok {
+		return h.redirectToDeviceLogin(c, req.UserCode)
+	}
+
+	page := devicePage{UserCode: req.UserCode}
+
+	user, err := h.store.GetUserById(ctx, session.UserID)
+	if err != nil {
+		return utils.RespondWithInternalError(c, "Could not get user information", err)
+	}
+	if !user.IsActive {
+		page.Result = "Your account is disabled, the device cannot be connected to it."
+		return h.renderDevicePage(c, page)
+	}
+
+	if c.Request().Method == http.MethodPost {
+		csrfToken, _ := utils.GetCookie(c, deviceCSRFCookie)
+		if csrfToken == "" || subtle.ConstantTimeCompare([]byte(csrfToken), []byte(req.CSRFToken)) != 1 {
+			page.Error = "The form has expired, please enter the code again."
+			return h.renderDevicePage(c, page)
+		}
+	}
+
+	// Without a code the user is asked for one
+	if req.UserCode == "" {
+		return h.renderDevicePage(c, page)
+	}
+
+	deviceCode, client, problem, err := h.pendingDeviceCode(ctx, req.UserCode)
+	if err != nil {
+		return utils.RespondWithInternalError(c, "Could not look up the device code", err)
+	}
+	if problem != "" {
+		page.Error = problem
+		return h.renderDevicePage(c, page)
+	}
+	page.UserCode = formatUserCode(deviceCode.UserCode)
+
+	// The decision only counts when it was posted from the page
+	decision := ""
+	if c.Request().Method == http.MethodPost {
+		decision = req.Decision
+	}
+
+	switch decision {
+	case "":
+		page.ClientName = client.Name
+		page.ClientWebsite = client.Website.String
+		page.Scopes = describeScopes(deviceCode.Scopes)
+		return h.renderDevicePage(c, page)
+
+	case consentDecisionDeny:
+		utils.DeleteCookie(c, deviceCSRFCookie)
+		if _, err := h.store.DenyOIDCDeviceCode(ctx, deviceCode.ID); err != nil {
+			return utils.RespondWithInternalError(c, "Could not deny the device request", err)
+		}
+		page.Result = fmt.Sprintf("You denied access to %s. You can close this page.", client.Name)
+		return h.renderDevicePage(c, page)
+
+	case consentDecisionAllow:
+		utils.DeleteCookie(c, deviceCSRFCookie)
+		approved, err := h.store.ApproveOIDCDeviceCode(ctx, sqlc.ApproveOIDCDeviceCodeParams{
+			ID:        deviceCode.ID,
+			UserID:    sql.NullInt32{Int32: user.ID, Valid: true},
+			SessionID: sql.NullInt32{Int32: session.ID, Valid: true},
+			AuthTime:  sql.NullTime{Time: session.CreatedAt, Valid: true},
+			Acr:       sql.NullString{String: sessionACR(session.Amr), Valid: true},
+			Amr:       session.Amr,
+		})
+		if err != nil {
+			return utils.RespondWithInternalError(c, "Could not approve the device request", err)
+		}
+		if approved == 0 {
+			page.Error = "The code is invalid or has expired."
+			return h.renderDevicePage(c, page)
+		}
+
+		// Like on the consent page, approving a third-party client grants it the scopes
+		if !client.IsFirstParty {
+			_, err = h.store.UpsertOIDCConsent(ctx, sqlc.UpsertOIDCConsentParams{
+				ClientID: client.ClientID,
+				UserID:   user.ID,
+				Scopes:   deviceCode.Scopes,
+			})
+			if err != nil {
+				return utils.RespondWithInternalError(c, "Could not store the user's consent", err)
+			}
+		}
+
+		page.Result = fmt.Sprintf("%s is now connected to your account. Return to your device to continue.", client.Name)
+		return h.renderDevicePage(c, page)
+
+	default:
+		page.Error = "Unknown decision, please try again."
+		return h.renderDevicePage(c, page)
+	}
+}
+
+// pendingDeviceCode finds the unanswered device request a user code belongs to, along
+// with its client. Problems with the code are returned as a message for the user.
+func (h *OIDCHandler) pendingDeviceCode(ctx context.Context, input string) (sqlc.OidcDeviceCode, sqlc.Client, string, error) {
+	const invalidCode = "The code is invalid or has expired."
+
+	userCode, ok := normalizeUserCode(input)
+	if !ok {
+		return sqlc.OidcDeviceCode{}, sqlc.Client{}, invalidCode, nil
+	}
+
+	deviceCode, err := h.store.GetPendingOIDCDeviceCodeByUserCode(ctx, userCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.OidcDeviceCode{}, sqlc.Client{}, invalidCode, nil
+		}
+		return sqlc.OidcDeviceCode{}, sqlc.Client{}, "", err
+	}
+
+	client, err := h.store.GetClientWithOIDCSettings(ctx, deviceCode.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.OidcDeviceCode{}, sqlc.Client{}, "The application requesting access is no longer available.", nil
+		}
+		return sqlc.OidcDeviceCode{}, sqlc.Client{}, "", err
+	}
+
+	return deviceCode, client, "", nil
+}
+
+// renderDevicePage renders the device verification page with a new CSRF token
+func (h *OIDCHandler) renderDevicePage(c echo.Context, page devicePage) error {
+	page.Action = pathDeviceVerification
+	if page.Result == "" {
+		csrfToken, err := utils.GenerateSecureToken(32)
+		if err != nil {
+			return utils.RespondWithInternalError(c, "Could not prepare the device verification page", err)
+		}
+		if err := utils.SetCookie(c, deviceCSRFCookie, csrfToken, deviceCSRFMaxAge); err != nil {
+			return utils.RespondWithInternalError(c, "Could not prepare the device verification page", err)
+		}
+		page.CSRFToken = csrfToken
+	}
+
+	// The decision buttons must not be clickable from inside another site's frame
+	c.Response().Header().Set("X-Frame-Options", "DENY")
+	c.Response().Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	return renderPage(c, "device.html", page)
+}
+
+// redirectToDeviceLogin sends the user agent to the CentralAuth login page,
+// which returns to the verification page with the code the user followed
+func (h *OIDCHandler) redirectToDeviceLogin(c echo.Context, userCode string) error {
+	verificationURL := fmt.Sprintf("%s://%s%s", c.Scheme(), c.Request().Host, pathDeviceVerification)
+	if userCode != "" {
+		verificationURL += "?" + url.Values{"user_code": {userCode}}.Encode()
+	}
+
+	params := url.Values{}
+	params.Set("redirect", verificationURL)
+	return c.Redirect(http.StatusFound, utils.AppendQuery(h.config.ClientURL+"/login", params))
+}
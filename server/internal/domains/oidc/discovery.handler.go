@@ -0,0 +1,158 @@
+package oidc
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// ProviderMetadata is the OpenID Provider discovery document (OpenID Connect Discovery section 3)
+type ProviderMetadata struct {
+	Issuer                                             string   `json:"issuer"`
+	AuthorizationEndpoint                              string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                                      string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                                   string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                                            string   `json:"jwks_uri,omitempty"`
+	EndSessionEndpoint                                 string   `json:"end_session_endpoint,omitempty"`
+	RevocationEndpoint                                 string   `json:"revocation_endpoint,omitempty"`
+	RevocationEndpointAuthMethodsSupported             []string `json:"revocation_endpoint_auth_methods_supported,omitempty"`
+	RevocationEndpointAuthSigningAlgValuesSupported    []string `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty"`
+	IntrospectionEndpoint                              string   `json:"introspection_endpoint,omitempty"`
+	IntrospectionEndpointAuthMethodsSupported          []string `json:"introspection_endpoint_auth_methods_supported,omitempty"`
+	IntrospectionEndpointAuthSigningAlgValuesSupported []string `json:"introspection_endpoint_auth_signing_alg_values_supported,omitempty"`
+	DeviceAuthorizationEndpoint                        string   `json:"device_authorization_endpoint,omitempty"`
+	RegistrationEndpoint                               string   `json:"registration_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint                 string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests                 bool     `json:"require_pushed_authorization_requests"`
+	RequestParameterSupported                          bool     `json:"request_parameter_supported"`
+	RequestURIParameterSupported                       bool     `json:"request_uri_parameter_supported"`
+	RequireRequestURIRegistration                      bool     `json:"require_request_uri_registration"`
+	RequireSignedRequestObject                         bool     `json:"require_signed_request_object"`
+	RequestObjectSigningAlgValuesSupported             []string `json:"request_object_signing_alg_values_supported"`
+	RequestObjectEncryptionAlgValuesSupported          []string `json:"request_object_encryption_alg_values_supported,omitempty"`
+	RequestObjectEncryptionEncValuesSupported          []string `json:"request_object_encryption_enc_values_supported,omitempty"`
+	ScopesSupported                                    []string `json:"scopes_supported"`
+	ResponseTypesSupported                             []string `json:"response_types_supported"`
+	ResponseModesSupported                             []string `json:"response_modes_supported"`
+	GrantTypesSupported                                []string `json:"grant_types_supported"`
+	SubjectTypesSupported                              []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported                   []string `json:"id_token_signing_alg_values_supported"`
+	UserinfoSigningAlgValuesSupported                  []string `json:"userinfo_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported                  []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValuesSupported         []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	ClaimsSupported                                    []string `json:"claims_supported"`
+	ACRValuesSupported                                 []string `json:"acr_values_supported"`
+	PromptValuesSupported                              []string `json:"prompt_values_supported"`
+	CodeChallengeMethodsSupported                      []string `json:"code_challenge_methods_supported"`
+	BackchannelLogoutSupported                         bool     `json:"backchannel_logout_supported"`
+	BackchannelLogoutSessionSupported                  bool     `json:"backchannel_logout_session_supported"`
+	FrontchannelLogoutSupported                        bool     `json:"frontchannel_logout_supported"`
+	FrontchannelLogoutSessionSupported                 bool     `json:"frontchannel_logout_session_supported"`
+}
+
+// Paths of the endpoints advertised in the discovery document
+const (
+	pathAuthorize     = "/oauth2/authorize"
+	pathToken         = "/oauth2/token"
+	pathUserinfo      = "/oauth2/userinfo"
+	pathJWKS          = "/.well-known/jwks.json"
+	pathEndSession    = "/oauth2/logout"
+	pathRevocation    = "/oauth2/revoke"
+	pathIntrospection = "/oauth2/introspect"
+	// RFC 9126 endpoint clients push their authorization parameters to
+	pathPushedAuthorization = "/oauth2/par"
+	// RFC 8628 device authorization endpoint, and the page where users enter the code
+	pathDeviceAuthorization = "/oauth2/device_authorization"
+	pathDeviceVerification  = "/oauth2/device"
+	// RFC 7591 registration endpoint; each client manages its registration below it (RFC 7592)
+	pathRegistration = "/oauth2/register"
+)
+
+// Discovery serves the OpenID Provider metadata (GET /.well-known/openid-configuration).
+// Endpoints are only advertised when a route is registered for them, and the
+// supported values come from the configuration and the handlers themselves.
+func (h *OIDCHandler) Discovery(c echo.Context) error {
+	registered := make(map[string]bool)
+	for _, route := range c.Echo().Routes() {
+		registered[route.Path] = true
+	}
+	endpoint := func(path string) string {
+		if !registered[path] {
+			return ""
+		}
+		return h.config.OIDC.Issuer + path
+	}
+
+	grantTypes := make([]string, 0)
+	for grantType := range h.grantHandlers() {
+		grantTypes = append(grantTypes, grantType)
+	}
+	sort.Strings(grantTypes)
+
+	metadata := ProviderMetadata{
+		Issuer:                                     h.config.OIDC.Issuer,
+		AuthorizationEndpoint:                      endpoint(pathAuthorize),
+		TokenEndpoint:                              endpoint(pathToken),
+		UserinfoEndpoint:                           endpoint(pathUserinfo),
+		JWKSURI:                                    endpoint(pathJWKS),
+		EndSessionEndpoint:                         endpoint(pathEndSession),
+		RevocationEndpoint:                         endpoint(pathRevocation),
+		IntrospectionEndpoint:                      endpoint(pathIntrospection),
+		DeviceAuthorizationEndpoint:                endpoint(pathDeviceAuthorization),
+		PushedAuthorizationRequestEndpoint:         endpoint(pathPushedAuthorization),
+		ScopesSupported:                            slices.Clone(supportedScopes),
+		ResponseTypesSupported:                     slices.Clone(defaultResponseTypes),
+		ResponseModesSupported:                     []string{"query"},
+		GrantTypesSupported:                        grantTypes,
+		SubjectTypesSupported:                      []string{"public"},
+		IDTokenSigningAlgValuesSupported:           utils.GetKeyStore().Algorithms(),
+		TokenEndpointAuthMethodsSupported:          slices.Clone(supportedAuthMethods),
+		TokenEndpointAuthSigningAlgValuesSupported: slices.Concat(privateKeyJWTAlgs, clientSecretJWTAlgs),
+		ClaimsSupported:                            slices.Clone(supportedClaims),
+		ACRValuesSupported:                         slices.Clone(supportedACRValues),
+		PromptValuesSupported:                      slices.Clone(supportedPrompts),
+		CodeChallengeMethodsSupported:              []string{utils.CodeChallengeMethodS256, utils.CodeChallengeMethodPlain},
+		BackchannelLogoutSupported:                 true,
+		BackchannelLogoutSessionSupported:          true,
+		FrontchannelLogoutSupported:                true,
+		FrontchannelLogoutSessionSupported:         true,
+		// Only the clients registered for it are required to push their requests
+		RequirePushedAuthorizationRequests: false,
+		// or to sign them, and request objects are only fetched from registered URIs
+		RequestParameterSupported:              true,
+		RequestURIParameterSupported:           true,
+		RequireRequestURIRegistration:          true,
+		RequireSignedRequestObject:             false,
+		RequestObjectSigningAlgValuesSupported: slices.Clone(requestObjectAlgs),
+	}
+
+	if utils.GetRequestObjectEncryptionKey() != nil {
+		metadata.RequestObjectEncryptionAlgValuesSupported = slices.Clone(utils.KeyEncryptionAlgs)
+		metadata.RequestObjectEncryptionEncValuesSupported = slices.Clone(utils.ContentEncryptionAlgs)
+	}
+
+	if h.config.OIDC.Registration.Mode != config.RegistrationDisabled {
+		metadata.RegistrationEndpoint = endpoint(pathRegistration)
+	}
+	if metadata.RevocationEndpoint != "" {
+		metadata.RevocationEndpointAuthMethodsSupported = slices.Clone(supportedAuthMethods)
+		metadata.RevocationEndpointAuthSigningAlgValuesSupported = metadata.TokenEndpointAuthSigningAlgValuesSupported
+	}
+	if metadata.IntrospectionEndpoint != "" {
+		// Public clients cannot introspect tokens
+		metadata.IntrospectionEndpointAuthMethodsSupported = slices.DeleteFunc(slices.Clone(supportedAuthMethods), func(method string) bool {
+			return method == authMethodNone
+		})
+		metadata.IntrospectionEndpointAuthSigningAlgValuesSupported = metadata.TokenEndpointAuthSigningAlgValuesSupported
+	}
+	// UserInfo responses are signed with the active key, unless it is the legacy shared secret
+	if alg := utils.GetKeyStore().SigningAlgorithm(); metadata.UserinfoEndpoint != "" && alg != "" && alg != utils.SigningAlgHS256 {
+		metadata.UserinfoSigningAlgValuesSupported = []string{alg}
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(int(utils.StatusCodeSuccess), metadata)
+}
@@ -0,0 +1,228 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Values of the token_type_hint parameter (RFC 7009 section 2.1)
+const (
+	tokenTypeHintAccessToken  = "access_token"
+	tokenTypeHintRefreshToken = "refresh_token"
+)
+
+// tokenLookup describes a token from one of the token tables to the introspecting client.
+// It returns nil when the table does not hold the token, and an inactive token when
+// the client may not learn about it.
+type tokenLookup func(ctx context.Context, client sqlc.Client, token string) (*IntrospectionResponse, error)
+
+// inactiveToken is the response for any token that cannot be used
+var inactiveToken = IntrospectionResponse{Active: false}
+
+// Introspect handles the token introspection endpoint (POST /oauth2/introspect).
+// Access tokens issued to OAuth clients and to the CentralAuth frontend are
+// both understood, as are refresh tokens. A token is inactive once the session
+// it was issued from has ended, whatever its expiry.
+// A client only learns about the tokens issued to it and the access tokens aimed at
+// it; the tokens of CentralAuth sessions are only described to first-party clients.
+// Any other token is reported inactive (RFC 7662 section 2.2).
+func (h *OIDCHandler) Introspect(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := new(IntrospectionRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Could not parse introspection request")
+	}
+
+	client, err := h.authenticateClient(c, req.ClientAuthentication)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if client.IsPublic {
+		// Anyone can claim a public client_id, so it cannot be allowed to probe tokens
+		return utils.RespondWithOAuthError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidClient, "Public clients cannot introspect tokens")
+	}
+
+	if req.Token == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "token is required")
+	}
+
+	res := &inactiveToken
+	for _, lookup := range h.tokenLookups(req.TokenTypeHint) {
+		found, err := lookup(ctx, client, req.Token)
+		if err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		if found != nil {
+			res = found
+			break
+		}
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeSuccess), res)
+}
+
+// tokenLookups returns the token tables to search, those matching the hint first.
+// An unknown hint is ignored as RFC 7662 section 2.1 allows.
+func (h *OIDCHandler) tokenLookups(hint string) []tokenLookup {
+	accessTokens := []tokenLookup{h.lookupOIDCAccessToken, h.lookupAccessToken}
+	refreshTokens := []tokenLookup{h.lookupOIDCRefreshToken, h.lookupRefreshToken}
+
+	if hint == tokenTypeHintRefreshToken {
+		return append(refreshTokens, accessTokens...)
+	}
+	return append(accessTokens, refreshTokens...)
+}
+
+// lookupOIDCAccessToken describes an access token issued by the token endpoint
+func (h *OIDCHandler) lookupOIDCAccessToken(ctx context.Context, client sqlc.Client, token string) (*IntrospectionResponse, error) {
+	accessToken, err := h.store.GetOIDCAccessTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if accessToken.ClientID != client.ClientID && !slices.Contains(accessToken.Audience, client.ClientID) {
+		return &inactiveToken, nil
+	}
+
+	active, err := h.sessionActive(ctx, accessToken.SessionID)
+	if err != nil || !active {
+		return &inactiveToken, err
+	}
+
+	sub := accessToken.ClientID
+	if accessToken.SubjectClientID.Valid {
+		sub = accessToken.SubjectClientID.String
+	}
+	if accessToken.UserID.Valid {
+		sub = strconv.Itoa(int(accessToken.UserID.Int32))
+	}
+	actor, err := storedActor(accessToken.Actor)
+	if err != nil {
+		return nil, err
+	}
+	res := &IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(accessToken.Scopes, " "),
+		ClientID:  accessToken.ClientID,
+		TokenType: "Bearer",
+		Exp:       accessToken.ExpiresAt.Unix(),
+		Iat:       accessToken.CreatedAt.Unix(),
+		Sub:       sub,
+		Iss:       h.config.OIDC.Issuer,
+		Act:       actor,
+	}
+	if len(accessToken.Audience) > 0 {
+		res.Aud = accessToken.Audience
+	}
+	return res, nil
+}
+
+// lookupOIDCRefreshToken describes a refresh token issued by the token endpoint
+func (h *OIDCHandler) lookupOIDCRefreshToken(ctx context.Context, client sqlc.Client, token string) (*IntrospectionResponse, error) {
+	refreshToken, err := h.store.GetOIDCRefreshTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if refreshToken.ClientID != client.ClientID {
+		return &inactiveToken, nil
+	}
+
+	active, err := h.sessionActive(ctx, refreshToken.SessionID)
+	if err != nil || !active {
+		return &inactiveToken, err
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(refreshToken.Scopes, " "),
+		ClientID:  refreshToken.ClientID,
+		TokenType: tokenTypeHintRefreshToken,
+		Exp:       refreshToken.ExpiresAt.Unix(),
+		Iat:       refreshToken.CreatedAt.Unix(),
+		Sub:       strconv.Itoa(int(refreshToken.UserID)),
+		Iss:       h.config.OIDC.Issuer,
+	}, nil
+}
+
+// lookupAccessToken describes an access token of a CentralAuth session.
+// The query only returns tokens whose session is still active.
+func (h *OIDCHandler) lookupAccessToken(ctx context.Context, client sqlc.Client, token string) (*IntrospectionResponse, error) {
+	accessToken, err := h.store.GetAccessTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !client.IsFirstParty || accessToken.ExpiresAt.Before(time.Now()) {
+		return &inactiveToken, nil
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Exp:       accessToken.ExpiresAt.Unix(),
+		Iat:       accessToken.CreatedAt.Unix(),
+		Sub:       strconv.Itoa(int(accessToken.UserID)),
+	}, nil
+}
+
+// lookupRefreshToken describes a refresh token of a CentralAuth session.
+// The query only returns tokens whose session is still active.
+func (h *OIDCHandler) lookupRefreshToken(ctx context.Context, client sqlc.Client, token string) (*IntrospectionResponse, error) {
+	refreshToken, err := h.store.GetRefreshTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !client.IsFirstParty || refreshToken.ExpiresAt.Before(time.Now()) {
+		return &inactiveToken, nil
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		ClientID:  refreshToken.ClientID.String,
+		TokenType: tokenTypeHintRefreshToken,
+		Exp:       refreshToken.ExpiresAt.Unix(),
+		Iat:       refreshToken.CreatedAt.Unix(),
+		Sub:       strconv.Itoa(int(refreshToken.UserID)),
+	}, nil
+}
+
+// sessionActive reports whether the CentralAuth session a token was issued from
+// is still active. Tokens issued to a client on its own behalf have no session.
+func (h *OIDCHandler) sessionActive(ctx context.Context, sessionID sql.NullInt32) (bool, error) {
+	if !sessionID.Valid {
+		return true, nil
+	}
+
+	_, err := h.store.GetSessionByID(ctx, sessionID.Int32)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
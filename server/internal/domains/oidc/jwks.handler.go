@@ -0,0 +1,19 @@
+package oidc
+
+import (
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS publishes the public keys tokens are signed with (GET /.well-known/jwks.json).
+// Keys used with the legacy HS256 algorithm are secret and never listed. The key clients
+// may encrypt request objects with is listed too, with use "enc".
+func (h *OIDCHandler) JWKS(c echo.Context) error {
+	jwks := utils.GetKeyStore().JWKS()
+	if key := utils.GetRequestObjectEncryptionKey(); key != nil {
+		jwks.Keys = append(jwks.Keys, key.PublicJWK())
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(int(utils.StatusCodeSuccess), jwks)
+}
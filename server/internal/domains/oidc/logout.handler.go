@@ -0,0 +1,260 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// logoutCSRFCookie holds the token the logout confirmation form has to echo back,
+	// so that another site cannot sign the user out
+	logoutCSRFCookie = "logout_csrf"
+	// logoutCSRFMaxAge is how long the user has to answer the logout confirmation page, in seconds
+	logoutCSRFMaxAge = 10 * 60
+	// logoutDecisionConfirm is the decision of a user confirming the logout
+	logoutDecisionConfirm = "logout"
+)
+
+// logoutConfirmationPage is the data rendered by the logout confirmation template
+type logoutConfirmationPage struct {
+	ClientName string
+	Action     string
+	Params     map[string]string
+	CSRFToken  string
+}
+
+// EndSession handles RP-initiated logout (GET/POST /oauth2/logout, OpenID Connect
+// RP-Initiated Logout 1.0). It ends the CentralAuth session of the user agent,
+// revokes the OIDC tokens the requesting client holds for the user, and returns
+// to the client's post_logout_redirect_uri when a registered one was given.
+// Unless an ID token hint of the session identifies the request as coming from a
+// client the user signed in to, the user confirms the logout first (section 2),
+// so that another site cannot sign the user out.
+func (h *OIDCHandler) EndSession(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := new(EndSessionRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Could not parse logout request")
+	}
+
+	var hint *utils.IDTokenClaims
+	if req.IDTokenHint != "" {
+		claims, err := h.parseIDTokenHint(req.IDTokenHint)
+		if err != nil {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "id_token_hint is invalid")
+		}
+		if req.ClientID != "" && !slices.Contains(claims.Audience, req.ClientID) {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_id does not match id_token_hint")
+		}
+		hint = claims
+	}
+
+	client, err := h.endSessionClient(ctx, req.ClientID, hint)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	// Only registered URIs are followed, the endpoint must not become an open redirector
+	if req.PostLogoutRedirectURI != "" {
+		if client == nil {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "post_logout_redirect_uri requires id_token_hint or client_id")
+		}
+		if !slices.Contains(client.PostLogoutRedirectUris, req.PostLogoutRedirectURI) {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "post_logout_redirect_uri is not registered for this client")
+		}
+	}
+
+	session, ok, err := h.currentSession(c)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	// A hint for another user means the session in this browser is not the one the client
+	// wants ended. A hint of an earlier session of the user does not spare the confirmation.
+	hintMatches := false
+	if ok && hint != nil {
+		if hint.Subject != strconv.Itoa(int(session.UserID)) {
+			ok = false
+		} else {
+			hintMatches = hint.SessionID == "" || hint.SessionID == strconv.Itoa(int(session.ID))
+		}
+	}
+
+	if ok && !hintMatches {
+		confirmed, err := h.logoutConfirmed(c, req)
+		if err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		if !confirmed {
+			return h.renderLogoutConfirmation(c, req, client)
+		}
+		if req.Decision != logoutDecisionConfirm {
+			return c.Redirect(http.StatusFound, h.config.ClientURL)
+		}
+	}
+
+	// The session ends before the tokens are revoked, so that the clients
+	// still holding tokens for it are notified through front- and back-channel logout
+	var frontchannelURIs []string
+	if ok {
+		if err := h.store.LogoutSession(ctx, session.ID); err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		if frontchannelURIs, err = h.frontchannelLogoutURIs(ctx, session.ID); err != nil {
+			return respondWithOAuthError(c, err)
+		}
+	}
+
+	// Without a session there is nothing to end: a hint alone, which may be stale
+	// or leaked, does not revoke any token
+	if ok && client != nil {
+		if err := h.revokeClientUserTokens(ctx, client.ClientID, session.UserID); err != nil {
+			return respondWithOAuthError(c, err)
+		}
+	}
+
+	if ok {
+		utils.DeleteTokensCookies(c)
+	}
+
+	redirectURL := h.config.ClientURL + "/login"
+	if req.PostLogoutRedirectURI != "" {
+		params := url.Values{}
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		redirectURL = utils.AppendQuery(req.PostLogoutRedirectURI, params)
+	}
+
+	if len(frontchannelURIs) == 0 {
+		return c.Redirect(http.StatusFound, redirectURL)
+	}
+	// The clients' logout pages are loaded in iframes before moving on
+	return renderPage(c, "frontchannel_logout.html", frontchannelLogoutPage{
+		LogoutURIs:    frontchannelURIs,
+		RedirectURL:   redirectURL,
+		TimeoutMillis: frontchannelLogoutTimeout.Milliseconds(),
+	})
+}
+
+// logoutConfirmed reports whether the request was posted from the logout confirmation
+// page, carrying the CSRF token the page was rendered with
+func (h *OIDCHandler) logoutConfirmed(c echo.Context, req *EndSessionRequest) (bool, error) {
+	if c.Request().Method != http.MethodPost || req.CSRFToken == "" {
+		return false, nil
+	}
+	csrfToken, _ := utils.GetCookie(c, logoutCSRFCookie)
+	if csrfToken == "" || subtle.ConstantTimeCompare([]byte(csrfToken), []byte(req.CSRFToken)) != 1 {
+		return false, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "The logout form has expired, please try again")
+	}
+	utils.DeleteCookie(c, logoutCSRFCookie)
+	return true, nil
+}
+
+// renderLogoutConfirmation asks the user whether to sign out.
+// The form posts the logout request back to the end session endpoint along with the decision.
+func (h *OIDCHandler) renderLogoutConfirmation(c echo.Context, req *EndSessionRequest, client *sqlc.Client) error {
+	csrfToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if err := utils.SetCookie(c, logoutCSRFCookie, csrfToken, logoutCSRFMaxAge); err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	page := logoutConfirmationPage{
+		Action:    pathEndSession,
+		Params:    make(map[string]string),
+		CSRFToken: csrfToken,
+	}
+	if client != nil {
+		page.ClientName = client.Name
+	}
+	values := req.Values()
+	for key := range values {
+		page.Params[key] = values.Get(key)
+	}
+	return renderPage(c, "logout.html", page)
+}
+
+// frontchannelLogoutTimeout is how long the front-channel logout page waits for
+// the clients' logout pages before redirecting anyway
+const frontchannelLogoutTimeout = 5 * time.Second
+
+// frontchannelLogoutPage is the data of the front-channel logout page
+type frontchannelLogoutPage struct {
+	LogoutURIs    []string
+	RedirectURL   string
+	TimeoutMillis int64
+}
+
+// frontchannelLogoutURIs returns the front-channel logout URIs of the clients
+// signed in through a session (OpenID Connect Front-Channel Logout 1.0 section 3).
+// iss and sid are added for clients that require them.
+func (h *OIDCHandler) frontchannelLogoutURIs(ctx context.Context, sessionID int32) ([]string, error) {
+	clients, err := h.store.ListFrontchannelLogoutClients(ctx, sql.NullInt32{Int32: sessionID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	uris := make([]string, 0, len(clients))
+	for _, client := range clients {
+		uri := client.FrontchannelLogoutUri.String
+		if client.FrontchannelLogoutSessionRequired {
+			params := url.Values{}
+			params.Set("iss", h.config.OIDC.Issuer)
+			params.Set("sid", strconv.Itoa(int(sessionID)))
+			uri = utils.AppendQuery(uri, params)
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
+
+// parseIDTokenHint verifies an ID token previously issued by this server.
+// Expired tokens are accepted since the hint only identifies the user and client.
+func (h *OIDCHandler) parseIDTokenHint(idToken string) (*utils.IDTokenClaims, error) {
+	claims := &utils.IDTokenClaims{}
+	if _, err := utils.ParseToken(idToken, claims, jwt.WithoutClaimsValidation()); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != h.config.OIDC.Issuer || claims.Subject == "" || len(claims.Audience) == 0 {
+		return nil, errors.New("not an ID token issued by this server")
+	}
+	return claims, nil
+}
+
+// endSessionClient resolves the client of a logout request from client_id or the
+// ID token hint. It returns nil when neither identifies a client.
+func (h *OIDCHandler) endSessionClient(ctx context.Context, clientID string, hint *utils.IDTokenClaims) (*sqlc.Client, error) {
+	if clientID == "" && hint != nil {
+		clientID = hint.AuthorizedParty
+		if clientID == "" {
+			clientID = hint.Audience[0]
+		}
+	}
+	if clientID == "" {
+		return nil, nil
+	}
+
+	client, err := h.store.GetClientWithOIDCSettings(ctx, clientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Unknown client")
+		}
+		return nil, err
+	}
+	return &client, nil
+}
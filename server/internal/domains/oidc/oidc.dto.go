@@ -0,0 +1,288 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+)
+
+// ==========
+// OIDC DTOs
+// ==========
+
+// === Authorize Dto ===
+// AuthorizeRequest holds the parameters of an authorization request.
+// They are read from the query string on GET and from the form body on POST.
+// With a request_uri the parameters are the ones the client pushed to /oauth2/par,
+// or those of the request object it refers to, which can also be sent in request.
+type AuthorizeRequest struct {
+	ResponseType        string `query:"response_type" form:"response_type"`
+	ClientID            string `query:"client_id" form:"client_id"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri"`
+	Scope               string `query:"scope" form:"scope"`
+	State               string `query:"state" form:"state"`
+	Nonce               string `query:"nonce" form:"nonce"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method"`
+	Prompt              string `query:"prompt" form:"prompt"`
+	MaxAge              string `query:"max_age" form:"max_age"`
+	LoginHint           string `query:"login_hint" form:"login_hint"`
+	ACRValues           string `query:"acr_values" form:"acr_values"`
+	RequestURI          string `query:"request_uri" form:"request_uri"`
+	Request             string `query:"request" form:"request"`
+
+	// fromRequestObject is set once the parameters were read from a verified request object
+	fromRequestObject bool
+}
+
+// Values encodes the request back into URL parameters, skipping empty ones.
+// A pushed request is referred to by its request_uri, so its parameters stay on the server,
+// and a request object is passed on as is, so its signature is checked again.
+func (r *AuthorizeRequest) Values() url.Values {
+	values := url.Values{"client_id": {r.ClientID}}
+	switch {
+	case r.RequestURI != "":
+		values.Set("request_uri", r.RequestURI)
+		return values
+	case r.Request != "":
+		values.Set("request", r.Request)
+		return values
+	}
+	return r.parameters()
+}
+
+// parameters encodes the authorization parameters of the request, skipping empty ones
+func (r *AuthorizeRequest) parameters() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("response_type", r.ResponseType)
+	set("client_id", r.ClientID)
+	set("redirect_uri", r.RedirectURI)
+	set("scope", r.Scope)
+	set("state", r.State)
+	set("nonce", r.Nonce)
+	set("code_challenge", r.CodeChallenge)
+	set("code_challenge_method", r.CodeChallengeMethod)
+	set("prompt", r.Prompt)
+	set("max_age", r.MaxAge)
+	set("login_hint", r.LoginHint)
+	set("acr_values", r.ACRValues)
+	return values
+}
+
+// setValues replaces the parameters of the request with the given ones
+func (r *AuthorizeRequest) setValues(values url.Values) {
+	*r = AuthorizeRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		Nonce:               values.Get("nonce"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Prompt:              values.Get("prompt"),
+		MaxAge:              values.Get("max_age"),
+		LoginHint:           values.Get("login_hint"),
+		ACRValues:           values.Get("acr_values"),
+	}
+}
+
+// ConsentRequest is the form posted from the consent page.
+// It carries the authorization request the user is answering.
+type ConsentRequest struct {
+	AuthorizeRequest
+	CSRFToken string `form:"csrf_token"`
+	Decision  string `form:"decision"`
+}
+
+// === Client Authentication Dto ===
+// ClientAuthentication holds the client credentials a request to the token, introspection
+// or revocation endpoint can carry in its form body
+type ClientAuthentication struct {
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	// Signed JWT for client_secret_jwt and private_key_jwt (RFC 7523 section 2.2)
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+}
+
+// === Token Dto ===
+// TokenRequest holds the form parameters accepted by the token endpoint
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	DeviceCode   string `form:"device_code"`
+	// Token exchange parameters (RFC 8693 section 2.1); audience and resource may be repeated
+	Resource           []string `form:"resource"`
+	Audience           []string `form:"audience"`
+	RequestedTokenType string   `form:"requested_token_type"`
+	SubjectToken       string   `form:"subject_token"`
+	SubjectTokenType   string   `form:"subject_token_type"`
+	ActorToken         string   `form:"actor_token"`
+	ActorTokenType     string   `form:"actor_token_type"`
+	ClientAuthentication
+}
+
+// TokenResponse is the successful token endpoint response (RFC 6749 section 5.1).
+// Token exchange responses also name the type of the issued token (RFC 8693 section 2.2.1).
+type TokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	RefreshToken    string `json:"refresh_token,omitempty"`
+	Scope           string `json:"scope,omitempty"`
+	IDToken         string `json:"id_token,omitempty"`
+}
+
+// === Pushed Authorization Dto ===
+// PushedAuthorizationRequest holds the form parameters of a pushed authorization request
+// (RFC 9126 section 2.1): the parameters of an authorization request along with the
+// credentials of the client sending it
+type PushedAuthorizationRequest struct {
+	AuthorizeRequest
+	ClientAuthentication
+}
+
+// PushedAuthorizationResponse is the reference to a pushed request (RFC 9126 section 2.2)
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// === Device Authorization Dto ===
+// DeviceAuthorizationRequest holds the form parameters of a device authorization request (RFC 8628 section 3.1)
+type DeviceAuthorizationRequest struct {
+	Scope string `form:"scope"`
+	ClientAuthentication
+}
+
+// DeviceAuthorizationResponse tells the device how to have the user approve it (RFC 8628 section 3.2)
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceVerificationRequest is the user code entered on the device verification page,
+// along with the user's decision once the request was shown to them
+type DeviceVerificationRequest struct {
+	UserCode  string `query:"user_code" form:"user_code"`
+	CSRFToken string `form:"csrf_token"`
+	Decision  string `form:"decision"`
+}
+
+// === Introspection Dto ===
+// IntrospectionRequest holds the form parameters of a token introspection request (RFC 7662 section 2.1)
+type IntrospectionRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientAuthentication
+}
+
+// IntrospectionResponse describes a token (RFC 7662 section 2.2).
+// Only active is set for tokens that are unknown, expired or revoked.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	// Set for tokens obtained through token exchange (RFC 8693 section 4.1)
+	Aud []string          `json:"aud,omitempty"`
+	Act *utils.ActorClaim `json:"act,omitempty"`
+}
+
+// === Revocation Dto ===
+// RevocationRequest holds the form parameters of a token revocation request (RFC 7009 section 2.1)
+type RevocationRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientAuthentication
+}
+
+// === Client Registration Dto ===
+// ClientMetadata is the metadata a client registers itself with (RFC 7591 section 2).
+// The same document is sent to update a registration (RFC 7592 section 2.2).
+type ClientMetadata struct {
+	RedirectURIs                       []string        `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod            string          `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes                         []string        `json:"grant_types,omitempty"`
+	ResponseTypes                      []string        `json:"response_types,omitempty"`
+	ClientName                         string          `json:"client_name,omitempty"`
+	ClientURI                          string          `json:"client_uri,omitempty"`
+	Scope                              string          `json:"scope,omitempty"`
+	JWKSURI                            string          `json:"jwks_uri,omitempty"`
+	JWKS                               json.RawMessage `json:"jwks,omitempty"`
+	UserinfoSignedResponseAlg          string          `json:"userinfo_signed_response_alg,omitempty"`
+	PostLogoutRedirectURIs             []string        `json:"post_logout_redirect_uris,omitempty"`
+	BackchannelLogoutURI               string          `json:"backchannel_logout_uri,omitempty"`
+	FrontchannelLogoutURI              string          `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired  bool            `json:"frontchannel_logout_session_required,omitempty"`
+	RequirePushedAuthorizationRequests bool            `json:"require_pushed_authorization_requests,omitempty"`
+	RequireSignedRequestObject         bool            `json:"require_signed_request_object,omitempty"`
+	RequestURIs                        []string        `json:"request_uris,omitempty"`
+}
+
+// ClientRegistrationRequest is the body of a registration or update request.
+// On update client_id must name the client being updated (RFC 7592 section 2.2).
+type ClientRegistrationRequest struct {
+	ClientID string `json:"client_id,omitempty"`
+	ClientMetadata
+}
+
+// ClientInformationResponse describes a registered client (RFC 7591 section 3.2.1).
+// The client secret is only included when it was just issued, the database keeps its hash.
+type ClientInformationResponse struct {
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64  `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   *int64 `json:"client_secret_expires_at,omitempty"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+	ClientMetadata
+}
+
+// === End Session Dto ===
+// EndSessionRequest holds the parameters of an RP-initiated logout request.
+// They are read from the query string on GET and from the form body on POST.
+// The confirmation page posts them back with its CSRF token and the user's decision.
+type EndSessionRequest struct {
+	IDTokenHint           string `query:"id_token_hint" form:"id_token_hint"`
+	ClientID              string `query:"client_id" form:"client_id"`
+	PostLogoutRedirectURI string `query:"post_logout_redirect_uri" form:"post_logout_redirect_uri"`
+	State                 string `query:"state" form:"state"`
+	CSRFToken             string `form:"csrf_token"`
+	Decision              string `form:"decision"`
+}
+
+// Values returns the logout request parameters, without those of the confirmation page
+func (r *EndSessionRequest) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("id_token_hint", r.IDTokenHint)
+	set("client_id", r.ClientID)
+	set("post_logout_redirect_uri", r.PostLogoutRedirectURI)
+	set("state", r.State)
+	return values
+}
@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"errors"
+	"log"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// oauthError is an error that maps directly onto an RFC 6749 error response
+type oauthError struct {
+	status      utils.StatusCode
+	code        utils.OAuthErrorCode
+	description string
+	// basicAuth is set when the client authenticated with HTTP Basic,
+	// in which case a 401 must carry a WWW-Authenticate challenge
+	basicAuth bool
+}
+
+func (e *oauthError) Error() string {
+	return string(e.code) + ": " + e.description
+}
+
+// newOAuthError creates an oauthError
+func newOAuthError(status utils.StatusCode, code utils.OAuthErrorCode, description string) *oauthError {
+	return &oauthError{status: status, code: code, description: description}
+}
+
+// respondWithOAuthError writes err as an OAuth error response.
+// Errors that are not oauthErrors are logged and reported as server_error.
+func respondWithOAuthError(c echo.Context, err error) error {
+	var oe *oauthError
+	if !errors.As(err, &oe) {
+		log.Printf("OAUTH SERVER ERROR: %v", err)
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeInternalError,
+			utils.OAuthErrorServerError,
+			"An unexpected error occurred while processing your request",
+		)
+	}
+
+	if oe.basicAuth && oe.status == utils.StatusCodeUnauthorized {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth2"`)
+	}
+	return utils.RespondWithOAuthError(c, oe.status, oe.code, oe.description)
+}
@@ -0,0 +1,177 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// OIDCHandler handles the OAuth 2.0 / OpenID Connect provider endpoints
+type OIDCHandler struct {
+	store  *db.Store
+	config *config.Config
+}
+
+// NewOIDCHandler creates a new OIDC handler
+func NewOIDCHandler(ah *domains.AppHandlers) *OIDCHandler {
+	return &OIDCHandler{
+		store:  ah.Store,
+		config: ah.Cfg,
+	}
+}
+
+// OAuth grant types understood by the token endpoint
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Defaults used when a client has not restricted the corresponding list
+var (
+	defaultResponseTypes = []string{"code"}
+	defaultGrantTypes    = []string{grantTypeAuthorizationCode}
+	defaultScopes        = []string{scopeOpenID, scopeProfile, scopeEmail}
+)
+
+// clientResponseTypes returns the response types a client may use
+func clientResponseTypes(client sqlc.Client) []string {
+	if len(client.AllowedResponseTypes) == 0 {
+		return defaultResponseTypes
+	}
+	return client.AllowedResponseTypes
+}
+
+// clientGrantTypes returns the grant types a client may use
+func clientGrantTypes(client sqlc.Client) []string {
+	if len(client.AllowedGrantTypes) == 0 {
+		return defaultGrantTypes
+	}
+	return client.AllowedGrantTypes
+}
+
+// clientScopes returns the scopes a client may request
+func clientScopes(client sqlc.Client) []string {
+	if len(client.AllowedScopes) == 0 {
+		return defaultScopes
+	}
+	return client.AllowedScopes
+}
+
+// redirectURIRegistered reports whether the redirect URI is registered for the client.
+// URIs are compared as exact strings, except that a client allowing loopback redirect
+// ports may use any port of a registered loopback redirect URI (RFC 8252 section 7.3).
+func redirectURIRegistered(client sqlc.Client, redirectURI string) bool {
+	if slices.Contains(client.RedirectUris, redirectURI) {
+		return true
+	}
+	if !client.AllowLoopbackRedirectPorts {
+		return false
+	}
+
+	requested, err := url.Parse(redirectURI)
+	if err != nil || !isLoopbackRedirectURI(requested) {
+		return false
+	}
+	for _, uri := range client.RedirectUris {
+		registered, err := url.Parse(uri)
+		if err != nil || !isLoopbackRedirectURI(registered) {
+			continue
+		}
+		if registered.Hostname() == requested.Hostname() &&
+			registered.EscapedPath() == requested.EscapedPath() &&
+			registered.RawQuery == requested.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackRedirectURI reports whether a redirect URI points to a native app
+// listening on the loopback interface (RFC 8252 section 7.3)
+func isLoopbackRedirectURI(u *url.URL) bool {
+	if u.Scheme != "http" || u.User != nil || u.Fragment != "" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// unsupportedScopes returns the requested scopes the client is not allowed to request
+func unsupportedScopes(client sqlc.Client, scopes []string) []string {
+	allowed := clientScopes(client)
+	denied := make([]string, 0)
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			denied = append(denied, scope)
+		}
+	}
+	return denied
+}
+
+// currentSession returns the active CentralAuth session of the user agent.
+// The op_session cookie is checked first, it is the only one browsers send on
+// navigations from relying parties. The access token cookie and the longer lived
+// refresh token cookie serve same-site requests, such as those of the login UI.
+func (h *OIDCHandler) currentSession(c echo.Context) (sqlc.Session, bool, error) {
+	ctx := c.Request().Context()
+
+	if cookie, err := c.Cookie(utils.OPSessionCookie); err == nil && cookie.Value != "" {
+		opSession, err := h.store.GetOPSessionByIdentifier(ctx, cookie.Value)
+		if err == nil {
+			return h.sessionByID(ctx, opSession.SessionID)
+		}
+		if err != sql.ErrNoRows {
+			return sqlc.Session{}, false, err
+		}
+	}
+
+	if accessToken, ok := c.Get("access_token").(string); ok && accessToken != "" {
+		tokenInfo, err := h.store.GetAccessTokenByToken(ctx, accessToken)
+		if err == nil && tokenInfo.ExpiresAt.After(time.Now()) {
+			return h.sessionByID(ctx, tokenInfo.SessionID)
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return sqlc.Session{}, false, err
+		}
+	}
+
+	if refreshToken, ok := c.Get("refresh_token").(string); ok && refreshToken != "" {
+		tokenInfo, err := h.store.GetRefreshTokenByToken(ctx, refreshToken)
+		if err == nil && tokenInfo.ExpiresAt.After(time.Now()) {
+			return h.sessionByID(ctx, tokenInfo.SessionID)
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return sqlc.Session{}, false, err
+		}
+	}
+
+	return sqlc.Session{}, false, nil
+}
+
+// sessionByID loads an active session, reporting false if it no longer exists
+func (h *OIDCHandler) sessionByID(ctx context.Context, sessionID int32) (sqlc.Session, bool, error) {
+	session, err := h.store.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sqlc.Session{}, false, nil
+		}
+		return sqlc.Session{}, false, err
+	}
+	return session, true, nil
+}
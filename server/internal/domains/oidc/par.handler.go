@@ -0,0 +1,132 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// requestURIPrefix starts every request_uri issued for a pushed request (RFC 9126 section 2.2)
+const requestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// PushedAuthorization handles the pushed authorization request endpoint (POST /oauth2/par).
+// An authenticated client posts the parameters of an authorization request and receives a
+// short-lived request_uri, which it then sends to the authorization endpoint in their place.
+// The parameters are checked here already, so errors reach the client rather than the user.
+func (h *OIDCHandler) PushedAuthorization(c echo.Context) error {
+	req := new(PushedAuthorizationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse pushed authorization request",
+		)
+	}
+
+	client, err := h.authenticateClient(c, req.ClientAuthentication)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	authorize := req.AuthorizeRequest
+	authorize.ClientID = client.ClientID
+	if authorize.RequestURI != "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "request_uri must not be pushed")
+	}
+	// A pushed request object is verified now and its parameters are stored in its place
+	if authorize.Request != "" {
+		if err := h.resolveRequestObject(c.Request().Context(), client, &authorize); err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		authorize.Request = ""
+	}
+	if client.RequireSignedRequestObject && !authorize.fromRequestObject {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "This client must send its authorization requests as signed request objects")
+	}
+	if authorize.RedirectURI == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "redirect_uri is required")
+	}
+	if !redirectURIRegistered(client, authorize.RedirectURI) {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "redirect_uri is not registered for this client")
+	}
+	if _, err := checkAuthorizeParameters(client, &authorize); err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	if err := h.store.DeleteExpiredOIDCPushedAuthorizationRequests(c.Request().Context()); err != nil {
+		log.Printf("Failed to delete expired pushed authorization requests: %v", err)
+	}
+
+	pushed, err := h.pushAuthorizeRequest(c.Request().Context(), authorize)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeCreated), PushedAuthorizationResponse{
+		RequestURI: pushed.RequestUri,
+		ExpiresIn:  int64(time.Until(pushed.ExpiresAt).Seconds()),
+	})
+}
+
+// pushAuthorizeRequest stores the parameters of an authorization request under a new request_uri
+func (h *OIDCHandler) pushAuthorizeRequest(ctx context.Context, req AuthorizeRequest) (sqlc.OidcPushedAuthorizationRequest, error) {
+	reference, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return sqlc.OidcPushedAuthorizationRequest{}, err
+	}
+
+	return h.store.CreateOIDCPushedAuthorizationRequest(ctx, sqlc.CreateOIDCPushedAuthorizationRequestParams{
+		RequestUri:        requestURIPrefix + reference,
+		ClientID:          req.ClientID,
+		Parameters:        req.Values().Encode(),
+		ExpiresAt:         time.Now().Add(h.config.OIDC.PushedRequestExpiry),
+		FromRequestObject: req.fromRequestObject,
+	})
+}
+
+// repushAuthorizeRequest pushes the resolved parameters of a request and returns a request
+// referring to them. Requests that came as a request object are continued this way, since
+// a request object is only accepted once.
+func (h *OIDCHandler) repushAuthorizeRequest(ctx context.Context, req AuthorizeRequest) (AuthorizeRequest, error) {
+	req.RequestURI, req.Request = "", ""
+	pushed, err := h.pushAuthorizeRequest(ctx, req)
+	if err != nil {
+		return AuthorizeRequest{}, err
+	}
+	return AuthorizeRequest{ClientID: req.ClientID, RequestURI: pushed.RequestUri}, nil
+}
+
+// resolvePushedRequest replaces the parameters of an authorization request carrying a
+// request_uri with the ones the client pushed (RFC 9126 section 4). Any other parameters
+// in the URL are ignored, and client_id must name the client that pushed the request.
+func (h *OIDCHandler) resolvePushedRequest(ctx context.Context, req *AuthorizeRequest) error {
+	invalidRequestURI := newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequestURI, "request_uri is invalid or has expired")
+
+	pushed, err := h.store.GetOIDCPushedAuthorizationRequest(ctx, req.RequestURI)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return invalidRequestURI
+		}
+		return err
+	}
+	if pushed.ClientID != req.ClientID {
+		return invalidRequestURI
+	}
+
+	values, err := url.ParseQuery(pushed.Parameters)
+	if err != nil {
+		return err
+	}
+	req.setValues(values)
+	req.RequestURI = pushed.RequestUri
+	req.fromRequestObject = pushed.FromRequestObject
+	return nil
+}
@@ -0,0 +1,475 @@
+package oidc
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// registrationAccessTokenLength is the number of random bytes in a registration access token
+const registrationAccessTokenLength = 32
+
+// Register handles dynamic client registration (POST /oauth2/register, RFC 7591 section 3).
+// Depending on the configured mode anyone may register a client, or only callers presenting
+// the initial access token. Registered clients are always third-party clients and may only
+// use the grant types, scopes and redirect URI hosts the registration policy allows.
+func (h *OIDCHandler) Register(c echo.Context) error {
+	ctx := c.Request().Context()
+	policy := h.config.OIDC.Registration
+
+	switch policy.Mode {
+	case config.RegistrationOpen:
+	case config.RegistrationToken:
+		token, err := bearerToken(c)
+		if err != nil {
+			return utils.RespondWithBearerError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, err.Error())
+		}
+		if token == "" {
+			return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, "", "")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(policy.InitialAccessToken)) != 1 {
+			return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The initial access token is invalid")
+		}
+	default:
+		return utils.RespondWithOAuthError(c, utils.StatusCodeForbidden, utils.OAuthErrorAccessDenied, "Dynamic client registration is disabled")
+	}
+
+	req := new(ClientRegistrationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidClientMetadata, "Could not parse client metadata")
+	}
+
+	params, err := h.resolveClientMetadata(req.ClientMetadata)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	params.ClientID = uuid.New().String()
+	if params.Name == "" {
+		params.Name = params.ClientID
+	}
+
+	registrationAccessToken, err := utils.GenerateSecureToken(registrationAccessTokenLength)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	registrationAccessTokenHash, err := utils.HashClientSecret(registrationAccessToken)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	// Only the hashes of the client secret and the registration access token are stored
+	var (
+		client       sqlc.Client
+		clientSecret string
+	)
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		client, err = q.CreateClient(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		err = q.SetClientRegistrationAccessToken(ctx, sqlc.SetClientRegistrationAccessTokenParams{
+			ClientID:                    client.ClientID,
+			RegistrationAccessTokenHash: sql.NullString{String: registrationAccessTokenHash, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Public clients and clients authenticating with their own keys have no secret
+		switch client.TokenEndpointAuthMethod {
+		case authMethodNone, authMethodPrivateKeyJWT:
+			return nil
+		}
+		clientSecret, err = h.createClientSecret(c, q, client)
+		return err
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeCreated), h.clientInformation(client, clientSecret, registrationAccessToken))
+}
+
+// GetRegistration returns the metadata of a self-registered client
+// (GET /oauth2/register/:client_id, RFC 7592 section 2.1)
+func (h *OIDCHandler) GetRegistration(c echo.Context) error {
+	client, err := h.registeredClient(c)
+	if client == nil {
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeSuccess), h.clientInformation(*client, "", ""))
+}
+
+// UpdateRegistration replaces the metadata of a self-registered client
+// (PUT /oauth2/register/:client_id, RFC 7592 section 2.2). The same policy applies as
+// when registering. The authentication method cannot change, since it decides whether
+// the client has a secret; a client needing another method registers again.
+func (h *OIDCHandler) UpdateRegistration(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	client, err := h.registeredClient(c)
+	if client == nil {
+		return err
+	}
+
+	req := new(ClientRegistrationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidClientMetadata, "Could not parse client metadata")
+	}
+	if req.ClientID != client.ClientID {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "client_id does not match the registered client")
+	}
+
+	params, err := h.resolveClientMetadata(req.ClientMetadata)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if params.TokenEndpointAuthMethod != client.TokenEndpointAuthMethod {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidClientMetadata, "token_endpoint_auth_method cannot be changed")
+	}
+	if params.Name == "" {
+		params.Name = client.ClientID
+	}
+
+	updated, err := h.store.UpdateClient(ctx, sqlc.UpdateClientParams{
+		ID:                                 client.ID,
+		Name:                               params.Name,
+		Description:                        client.Description,
+		Website:                            params.Website,
+		RedirectUris:                       params.RedirectUris,
+		IsPublic:                           params.IsPublic,
+		OidcEnabled:                        params.OidcEnabled,
+		AllowedScopes:                      params.AllowedScopes,
+		AllowedGrantTypes:                  params.AllowedGrantTypes,
+		AllowedResponseTypes:               params.AllowedResponseTypes,
+		UserinfoSignedResponseAlg:          params.UserinfoSignedResponseAlg,
+		PostLogoutRedirectUris:             params.PostLogoutRedirectUris,
+		BackchannelLogoutUri:               params.BackchannelLogoutUri,
+		FrontchannelLogoutUri:              params.FrontchannelLogoutUri,
+		FrontchannelLogoutSessionRequired:  params.FrontchannelLogoutSessionRequired,
+		IsFirstParty:                       params.IsFirstParty,
+		AllowedOrigins:                     client.AllowedOrigins,
+		AllowLoopbackRedirectPorts:         client.AllowLoopbackRedirectPorts,
+		TokenEndpointAuthMethod:            params.TokenEndpointAuthMethod,
+		Jwks:                               params.Jwks,
+		JwksUri:                            params.JwksUri,
+		RequirePushedAuthorizationRequests: params.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         params.RequireSignedRequestObject,
+		RequestUris:                        params.RequestUris,
+		// Token exchange audiences are granted by administrators only
+		TokenExchangeAudiences: client.TokenExchangeAudiences,
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(int(utils.StatusCodeSuccess), h.clientInformation(updated, "", ""))
+}
+
+// DeleteRegistration removes a self-registered client along with its secrets and tokens
+// (DELETE /oauth2/register/:client_id, RFC 7592 section 2.3)
+func (h *OIDCHandler) DeleteRegistration(c echo.Context) error {
+	client, err := h.registeredClient(c)
+	if client == nil {
+		return err
+	}
+
+	if err := h.store.DeleteClient(c.Request().Context(), client.ID); err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	return c.NoContent(int(utils.StatusCodeNoContent))
+}
+
+// registeredClient loads the client named in the path of a client configuration request,
+// authenticated by its registration access token (RFC 7592 section 3). Unknown clients
+// and clients created by administrators, which have no such token, are reported like an
+// invalid token so the endpoint does not reveal which clients exist. It responds itself
+// and returns no client when the request is rejected.
+func (h *OIDCHandler) registeredClient(c echo.Context) (*sqlc.Client, error) {
+	token, err := bearerToken(c)
+	if err != nil {
+		return nil, utils.RespondWithBearerError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, err.Error())
+	}
+	if token == "" {
+		return nil, utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, "", "")
+	}
+
+	invalidToken := func() error {
+		return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The registration access token is invalid")
+	}
+
+	client, err := h.store.GetClientByClientID(c.Request().Context(), c.Param("client_id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, invalidToken()
+		}
+		return nil, respondWithOAuthError(c, err)
+	}
+	if !client.RegistrationAccessTokenHash.Valid || !utils.VerifyClientSecret(client.RegistrationAccessTokenHash.String, token) {
+		return nil, invalidToken()
+	}
+	return &client, nil
+}
+
+// createClientSecret issues the secret of a newly registered confidential client.
+// Clients using client_secret_jwt also get an encrypted copy to verify their assertions with.
+func (h *OIDCHandler) createClientSecret(c echo.Context, q *sqlc.Queries, client sqlc.Client) (string, error) {
+	secret, secretHash, err := utils.GenerateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	var secretEncrypted []byte
+	if client.TokenEndpointAuthMethod == authMethodClientSecretJWT {
+		secretEncrypted, err = utils.Encrypt(utils.ClientSecretEncryptionKey(h.config), []byte(secret))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = q.CreateClientSecret(c.Request().Context(), sqlc.CreateClientSecretParams{
+		ClientID:        client.ClientID,
+		SecretHash:      secretHash,
+		SecretEncrypted: secretEncrypted,
+	})
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// resolveClientMetadata checks client metadata against the registration policy and turns
+// it into the settings of the client, leaving the client_id to the caller. Omitted values
+// get the defaults of RFC 7591 section 2, and scope defaults to every scope the policy allows.
+func (h *OIDCHandler) resolveClientMetadata(metadata ClientMetadata) (sqlc.CreateClientParams, error) {
+	policy := h.config.OIDC.Registration
+	invalidMetadata := func(format string, args ...any) error {
+		return newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidClientMetadata, fmt.Sprintf(format, args...))
+	}
+	invalidRedirectURI := func(format string, args ...any) error {
+		return newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRedirectURI, fmt.Sprintf(format, args...))
+	}
+
+	grantTypes := metadata.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = slices.Clone(defaultGrantTypes)
+	}
+	grantHandlers := h.grantHandlers()
+	for _, grantType := range grantTypes {
+		if _, ok := grantHandlers[grantType]; !ok || !slices.Contains(policy.GrantTypes, grantType) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Grant type not allowed for registered clients: %s", grantType)
+		}
+	}
+	usesRedirects := slices.Contains(grantTypes, grantTypeAuthorizationCode)
+
+	// Only the code response type is supported, and it comes with the authorization_code grant
+	responseTypes := metadata.ResponseTypes
+	if len(responseTypes) == 0 && usesRedirects {
+		responseTypes = slices.Clone(defaultResponseTypes)
+	}
+	for _, responseType := range responseTypes {
+		if !slices.Contains(defaultResponseTypes, responseType) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Unsupported response type: %s", responseType)
+		}
+	}
+	if usesRedirects != slices.Contains(responseTypes, "code") {
+		return sqlc.CreateClientParams{}, invalidMetadata("The code response type and the authorization_code grant type must be registered together")
+	}
+
+	scopes := utils.ParseScope(metadata.Scope)
+	if len(scopes) == 0 {
+		scopes = slices.Clone(policy.Scopes)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(policy.Scopes, scope) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Scope not allowed for registered clients: %s", scope)
+		}
+	}
+
+	if usesRedirects && len(metadata.RedirectURIs) == 0 {
+		return sqlc.CreateClientParams{}, invalidRedirectURI("redirect_uris is required for the authorization_code grant type")
+	}
+	for _, uri := range metadata.RedirectURIs {
+		if !utils.IsValidRedirectURI(uri) || len(uri) > 255 {
+			return sqlc.CreateClientParams{}, invalidRedirectURI("Invalid redirect URI: %s", uri)
+		}
+		if !registrationHostAllowed(policy.RedirectHosts, uri) {
+			return sqlc.CreateClientParams{}, invalidRedirectURI("Redirect URI host not allowed for registered clients: %s", uri)
+		}
+	}
+	for _, uri := range metadata.PostLogoutRedirectURIs {
+		if !utils.IsValidRedirectURI(uri) || len(uri) > 255 || !registrationHostAllowed(policy.RedirectHosts, uri) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Invalid or disallowed post_logout_redirect_uri: %s", uri)
+		}
+	}
+
+	// Logout notifications carry session identifiers, so they are held to the same hosts
+	for _, field := range []struct{ name, uri string }{
+		{"backchannel_logout_uri", metadata.BackchannelLogoutURI},
+		{"frontchannel_logout_uri", metadata.FrontchannelLogoutURI},
+	} {
+		if field.uri != "" && (!isWebURL(field.uri) || !registrationHostAllowed(policy.RedirectHosts, field.uri)) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Invalid or disallowed %s: %s", field.name, field.uri)
+		}
+	}
+	// The server fetches request objects and keys from these, so they are held to the same hosts too
+	for _, uri := range metadata.RequestURIs {
+		if !strings.HasPrefix(uri, "https://") || !isWebURL(uri) || !registrationHostAllowed(policy.RedirectHosts, uri) {
+			return sqlc.CreateClientParams{}, invalidMetadata("Invalid or disallowed request_uri: %s", uri)
+		}
+	}
+	if uri := metadata.JWKSURI; uri != "" && (!strings.HasPrefix(uri, "https://") || !isWebURL(uri) || !registrationHostAllowed(policy.RedirectHosts, uri)) {
+		return sqlc.CreateClientParams{}, invalidMetadata("Invalid or disallowed jwks_uri: %s", uri)
+	}
+	if metadata.ClientURI != "" && !isWebURL(metadata.ClientURI) {
+		return sqlc.CreateClientParams{}, invalidMetadata("Invalid client_uri: %s", metadata.ClientURI)
+	}
+
+	if len(metadata.ClientName) > 100 {
+		return sqlc.CreateClientParams{}, invalidMetadata("client_name must be at most 100 characters")
+	}
+	// UserInfo responses can only be signed with the algorithm of the active key
+	if metadata.UserinfoSignedResponseAlg != "" && (metadata.UserinfoSignedResponseAlg == utils.SigningAlgHS256 || metadata.UserinfoSignedResponseAlg != utils.GetKeyStore().SigningAlgorithm()) {
+		return sqlc.CreateClientParams{}, invalidMetadata("Unsupported userinfo_signed_response_alg: %s", metadata.UserinfoSignedResponseAlg)
+	}
+
+	method := metadata.TokenEndpointAuthMethod
+	if method != "" && !slices.Contains(supportedAuthMethods, method) {
+		return sqlc.CreateClientParams{}, invalidMetadata("Unsupported token_endpoint_auth_method: %s", method)
+	}
+	isPublic := method == authMethodNone
+	if isPublic && slices.Contains(grantTypes, grantTypeClientCredentials) {
+		return sqlc.CreateClientParams{}, invalidMetadata("Public clients cannot use the client_credentials grant type")
+	}
+	var jwks string
+	if len(metadata.JWKS) > 0 && string(metadata.JWKS) != "null" {
+		jwks = string(metadata.JWKS)
+	}
+	auth, err := utils.ResolveClientAuthentication(isPublic, method, jwks, metadata.JWKSURI)
+	if err != nil {
+		return sqlc.CreateClientParams{}, invalidMetadata("%s", err.Error())
+	}
+
+	return sqlc.CreateClientParams{
+		Name:                 metadata.ClientName,
+		Website:              sql.NullString{String: metadata.ClientURI, Valid: metadata.ClientURI != ""},
+		RedirectUris:         nonNil(metadata.RedirectURIs),
+		IsPublic:             isPublic,
+		OidcEnabled:          true,
+		AllowedScopes:        scopes,
+		AllowedGrantTypes:    grantTypes,
+		AllowedResponseTypes: nonNil(responseTypes),
+		UserinfoSignedResponseAlg: sql.NullString{
+			String: metadata.UserinfoSignedResponseAlg,
+			Valid:  metadata.UserinfoSignedResponseAlg != "",
+		},
+		PostLogoutRedirectUris:            nonNil(metadata.PostLogoutRedirectURIs),
+		BackchannelLogoutUri:              sql.NullString{String: metadata.BackchannelLogoutURI, Valid: metadata.BackchannelLogoutURI != ""},
+		FrontchannelLogoutUri:             sql.NullString{String: metadata.FrontchannelLogoutURI, Valid: metadata.FrontchannelLogoutURI != ""},
+		FrontchannelLogoutSessionRequired: metadata.FrontchannelLogoutSessionRequired,
+		// Users always consent to self-registered clients
+		IsFirstParty:                       false,
+		AllowedOrigins:                     []string{},
+		TokenEndpointAuthMethod:            auth.Method,
+		Jwks:                               auth.JWKS,
+		JwksUri:                            auth.JWKSURI,
+		RequirePushedAuthorizationRequests: metadata.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:         metadata.RequireSignedRequestObject,
+		RequestUris:                        nonNil(metadata.RequestURIs),
+		TokenExchangeAudiences:             []string{},
+	}, nil
+}
+
+// clientInformation describes a registered client to itself (RFC 7591 section 3.2.1).
+// The client secret and registration access token are only known right after registration.
+func (h *OIDCHandler) clientInformation(client sqlc.Client, clientSecret, registrationAccessToken string) ClientInformationResponse {
+	res := ClientInformationResponse{
+		ClientID:                client.ClientID,
+		ClientSecret:            clientSecret,
+		ClientIDIssuedAt:        client.CreatedAt.Unix(),
+		RegistrationAccessToken: registrationAccessToken,
+		RegistrationClientURI:   h.config.OIDC.Issuer + pathRegistration + "/" + url.PathEscape(client.ClientID),
+		ClientMetadata: ClientMetadata{
+			RedirectURIs:                       client.RedirectUris,
+			TokenEndpointAuthMethod:            client.TokenEndpointAuthMethod,
+			GrantTypes:                         clientGrantTypes(client),
+			ResponseTypes:                      client.AllowedResponseTypes,
+			ClientName:                         client.Name,
+			ClientURI:                          client.Website.String,
+			Scope:                              strings.Join(clientScopes(client), " "),
+			JWKSURI:                            client.JwksUri.String,
+			UserinfoSignedResponseAlg:          client.UserinfoSignedResponseAlg.String,
+			PostLogoutRedirectURIs:             client.PostLogoutRedirectUris,
+			BackchannelLogoutURI:               client.BackchannelLogoutUri.String,
+			FrontchannelLogoutURI:              client.FrontchannelLogoutUri.String,
+			FrontchannelLogoutSessionRequired:  client.FrontchannelLogoutSessionRequired,
+			RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
+			RequireSignedRequestObject:         client.RequireSignedRequestObject,
+			RequestURIs:                        client.RequestUris,
+		},
+	}
+	if client.Jwks.Valid {
+		res.JWKS = json.RawMessage(client.Jwks.String)
+	}
+	if clientSecret != "" {
+		// The secret does not expire until it is rotated
+		expiresAt := int64(0)
+		res.ClientSecretExpiresAt = &expiresAt
+	}
+	return res
+}
+
+// registrationHostAllowed reports whether self-registered clients may use a URI on its host.
+// An entry like *.example.com matches every subdomain of example.com; without entries any host is allowed.
+func registrationHostAllowed(hosts []string, rawURI string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(rawURI)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebURL reports whether a URI is an absolute http or https URL short enough to be stored
+func isWebURL(rawURI string) bool {
+	u, err := url.Parse(rawURI)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && len(rawURI) <= 255
+}
+
+// nonNil returns an empty slice for omitted metadata,
+// since a nil slice would be stored as NULL in a NOT NULL array column
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
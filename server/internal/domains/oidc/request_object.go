@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// requestObjectAlgs are the algorithms request objects may be signed with. Unsigned
+// request objects (alg none) are rejected, they would not protect the parameters.
+var requestObjectAlgs = slices.Concat(privateKeyJWTAlgs, clientSecretJWTAlgs)
+
+// maxRequestObjectLifetime bounds how far ahead a request object may expire, and so how
+// long its jti is remembered to keep it from being replayed
+const maxRequestObjectLifetime = time.Hour
+
+// requestObjectMaxBytes bounds the size of a request object fetched from a request_uri
+const requestObjectMaxBytes = 64 << 10
+
+// requestObjectHTTPClient fetches request objects from registered request_uris
+var requestObjectHTTPClient = &http.Client{
+	Timeout: 5 * time.Second,
+	// The request object must be served at the registered URI itself
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// resolveRequestObject verifies the request object of an authorization request, sent by
+// value in request or by reference in request_uri (RFC 9101 section 5). Its parameters
+// take precedence over those in the URL, and the request is marked as coming from it.
+func (h *OIDCHandler) resolveRequestObject(ctx context.Context, client sqlc.Client, req *AuthorizeRequest) error {
+	requestObject := req.Request
+	if requestObject == "" {
+		fetched, err := fetchRequestObject(ctx, client, req.RequestURI)
+		if err != nil {
+			return newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequestURI, err.Error())
+		}
+		requestObject = fetched
+	}
+
+	claims, err := h.parseRequestObject(ctx, client, requestObject)
+	if err != nil {
+		if errors.Is(err, errInvalidClientJWT) {
+			return newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequestObject, err.Error())
+		}
+		return err
+	}
+
+	values := req.parameters()
+	for name, value := range claims {
+		switch value := value.(type) {
+		case string:
+			values.Set(name, value)
+		case json.Number:
+			// max_age may be sent as a number
+			values.Set(name, value.String())
+		}
+	}
+
+	request, requestURI := req.Request, req.RequestURI
+	req.setValues(values)
+	req.Request, req.RequestURI = request, requestURI
+	req.fromRequestObject = true
+	return nil
+}
+
+// parseRequestObject decrypts a request object if it is encrypted, verifies that the
+// client signed it and returns its claims. Errors caused by the object wrap errInvalidClientJWT.
+func (h *OIDCHandler) parseRequestObject(ctx context.Context, client sqlc.Client, requestObject string) (jwt.MapClaims, error) {
+	if utils.IsJWE(requestObject) {
+		key := utils.GetRequestObjectEncryptionKey()
+		if key == nil {
+			return nil, invalidClientJWT(errors.New("encrypted request objects are not supported"))
+		}
+		// Encrypted request objects are nested, the plaintext is the signed request object
+		plaintext, err := utils.DecryptJWE(requestObject, key)
+		if err != nil {
+			return nil, invalidClientJWT(err)
+		}
+		requestObject = string(plaintext)
+	}
+
+	claims := jwt.MapClaims{}
+	if err := h.parseClientJWT(ctx, client, requestObject, requestObjectAlgs, claims,
+		jwt.WithJSONNumber(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clientAssertionLeeway),
+	); err != nil {
+		return nil, err
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp.After(time.Now().Add(maxRequestObjectLifetime)) {
+		return nil, invalidClientJWT(errors.New("exp must be at most an hour ahead"))
+	}
+
+	// iss and aud are optional, but must name the client and this server when set
+	if iss, ok := claims["iss"]; ok && iss != client.ClientID {
+		return nil, invalidClientJWT(errors.New("iss must be the client_id"))
+	}
+	if _, ok := claims["aud"]; ok {
+		audience, err := claims.GetAudience()
+		if err != nil || !slices.Contains(audience, h.config.OIDC.Issuer) {
+			return nil, invalidClientJWT(errors.New("aud must include the issuer"))
+		}
+	}
+	if clientID, ok := claims["client_id"]; ok && clientID != client.ClientID {
+		return nil, invalidClientJWT(errors.New("client_id does not match the request"))
+	}
+	if _, ok := claims["request"]; ok {
+		return nil, invalidClientJWT(errors.New("request must not be nested"))
+	}
+	if _, ok := claims["request_uri"]; ok {
+		return nil, invalidClientJWT(errors.New("request_uri must not be nested"))
+	}
+
+	// Each request object is accepted once, its jti is remembered until it expires
+	jti, _ := claims["jti"].(string)
+	if jti == "" || len(jti) > 255 {
+		return nil, invalidClientJWT(errors.New("jti is required and must be at most 255 characters"))
+	}
+	recorded, err := h.store.RecordRequestObjectJTI(ctx, sqlc.RecordRequestObjectJTIParams{
+		ClientID:  client.ClientID,
+		Jti:       jti,
+		ExpiresAt: exp.Time,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if recorded == 0 {
+		return nil, invalidClientJWT(errors.New("the request object has already been used"))
+	}
+
+	if err := h.store.DeleteExpiredRequestObjectJTIs(ctx); err != nil {
+		log.Printf("Failed to delete expired request object identifiers: %v", err)
+	}
+	return claims, nil
+}
+
+// fetchRequestObject downloads a request object from a request_uri the client registered.
+// Only registered URIs are fetched, so the server cannot be made to request arbitrary URLs.
+func fetchRequestObject(ctx context.Context, client sqlc.Client, uri string) (string, error) {
+	// The fragment may carry a hash of the content and is not part of the registration
+	registered, _, _ := strings.Cut(uri, "#")
+	if !slices.Contains(client.RequestUris, registered) {
+		return "", errors.New("request_uri is not registered for this client")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registered, nil)
+	if err != nil {
+		return "", errors.New("request_uri is invalid")
+	}
+	req.Header.Set("Accept", "application/oauth-authz-req+jwt")
+
+	resp, err := requestObjectHTTPClient.Do(req)
+	if err != nil {
+		return "", errors.New("could not fetch the request object")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching the request object returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, requestObjectMaxBytes))
+	if err != nil {
+		return "", errors.New("could not fetch the request object")
+	}
+	return strings.TrimSpace(string(body)), nil
+}
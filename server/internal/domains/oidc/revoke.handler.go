@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// errTokenOfOtherClient is returned when a client tries to revoke a token issued to another client
+var errTokenOfOtherClient = newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorUnauthorizedClient, "Token was issued to another client")
+
+// tokenRevoker revokes a token from one of the token tables if the client owns it.
+// It reports whether the table held the token.
+type tokenRevoker func(ctx context.Context, client sqlc.Client, token string) (bool, error)
+
+// Revoke handles the token revocation endpoint (POST /oauth2/revoke).
+// Clients can revoke their own access and refresh tokens; revoking a refresh
+// token also revokes the access token issued with it. Unknown, expired and
+// already revoked tokens are answered with 200 as RFC 7009 section 2.2 requires.
+func (h *OIDCHandler) Revoke(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := new(RevocationRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Could not parse revocation request")
+	}
+
+	client, err := h.authenticateClient(c, req.ClientAuthentication)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	if req.Token == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "token is required")
+	}
+
+	revokers := []tokenRevoker{h.revokeOIDCAccessToken, h.revokeOIDCRefreshToken}
+	if req.TokenTypeHint == tokenTypeHintRefreshToken {
+		revokers = []tokenRevoker{h.revokeOIDCRefreshToken, h.revokeOIDCAccessToken}
+	}
+
+	for _, revoke := range revokers {
+		found, err := revoke(ctx, client, req.Token)
+		if err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		if found {
+			break
+		}
+	}
+
+	return c.NoContent(int(utils.StatusCodeSuccess))
+}
+
+// revokeOIDCAccessToken revokes an access token issued by the token endpoint
+func (h *OIDCHandler) revokeOIDCAccessToken(ctx context.Context, client sqlc.Client, token string) (bool, error) {
+	accessToken, err := h.store.GetOIDCAccessTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if accessToken.ClientID != client.ClientID {
+		return true, errTokenOfOtherClient
+	}
+
+	return true, h.store.RevokeOIDCAccessToken(ctx, token)
+}
+
+// revokeOIDCRefreshToken revokes a refresh token and the access token issued with it
+func (h *OIDCHandler) revokeOIDCRefreshToken(ctx context.Context, client sqlc.Client, token string) (bool, error) {
+	refreshToken, err := h.store.GetOIDCRefreshTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if refreshToken.ClientID != client.ClientID {
+		return true, errTokenOfOtherClient
+	}
+
+	return true, h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.RevokeOIDCRefreshToken(ctx, token); err != nil {
+			return err
+		}
+		return q.RevokeOIDCAccessTokenByID(ctx, refreshToken.AccessTokenID)
+	})
+}
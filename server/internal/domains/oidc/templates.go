@@ -0,0 +1,26 @@
+package oidc
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// templates are the pages the provider renders itself rather than through the frontend
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// renderPage writes one of the provider's HTML pages
+func renderPage(c echo.Context, name string, data any) error {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.HTMLBlob(http.StatusOK, buf.Bytes())
+}
@@ -0,0 +1,285 @@
+package oidc
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// grantHandler processes one grant type for an authenticated client
+type grantHandler func(c echo.Context, client sqlc.Client, req *TokenRequest) error
+
+// grantHandlers returns the grant types supported by the token endpoint
+func (h *OIDCHandler) grantHandlers() map[string]grantHandler {
+	return map[string]grantHandler{
+		grantTypeAuthorizationCode: h.authorizationCodeGrant,
+		grantTypeRefreshToken:      h.refreshTokenGrant,
+		grantTypeClientCredentials: h.clientCredentialsGrant,
+		grantTypeDeviceCode:        h.deviceCodeGrant,
+		grantTypeTokenExchange:     h.tokenExchangeGrant,
+	}
+}
+
+// Token handles the OAuth 2.0 token endpoint (POST /oauth2/token)
+func (h *OIDCHandler) Token(c echo.Context) error {
+	req := new(TokenRequest)
+	if err := c.Bind(req); err != nil {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"Could not parse token request",
+		)
+	}
+
+	if req.GrantType == "" {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorInvalidRequest,
+			"grant_type is required",
+		)
+	}
+
+	handler, ok := h.grantHandlers()[req.GrantType]
+	if !ok {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorUnsupportedGrantType,
+			"Grant type is not supported",
+		)
+	}
+
+	client, err := h.authenticateClient(c, req.ClientAuthentication)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	if !slices.Contains(clientGrantTypes(client), req.GrantType) {
+		return utils.RespondWithOAuthError(
+			c,
+			utils.StatusCodeBadRequest,
+			utils.OAuthErrorUnauthorizedClient,
+			"Client is not allowed to use this grant type",
+		)
+	}
+
+	return handler(c, client, req)
+}
+
+// authorizationCodeGrant exchanges an authorization code for tokens (RFC 6749 section 4.1.3)
+func (h *OIDCHandler) authorizationCodeGrant(c echo.Context, client sqlc.Client, req *TokenRequest) error {
+	ctx := c.Request().Context()
+
+	if req.Code == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "code is required")
+	}
+
+	// Marking the code as used and reading it happen in a single statement,
+	// so two concurrent requests cannot both redeem the same code. The statement
+	// only matches codes issued to the client, another client cannot use them up.
+	authCode, err := h.store.ConsumeOIDCAuthCode(ctx, sqlc.ConsumeOIDCAuthCodeParams{
+		Code:     req.Code,
+		ClientID: client.ClientID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Authorization code is invalid, expired, already used or issued to another client")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	if req.RedirectURI != authCode.RedirectUri {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+
+	// PKCE (RFC 7636)
+	if authCode.CodeChallenge.Valid {
+		if req.CodeVerifier == "" {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "code_verifier is required")
+		}
+		if !utils.VerifyCodeChallenge(req.CodeVerifier, authCode.CodeChallenge.String, authCode.CodeChallengeMethod.String) {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "code_verifier does not match the code challenge")
+		}
+	} else {
+		if client.IsPublic {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Public clients must use PKCE")
+		}
+		if req.CodeVerifier != "" {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "code_verifier was sent but the authorization request had no code challenge")
+		}
+	}
+
+	user, err := h.store.GetUserById(ctx, authCode.UserID)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if !user.IsActive {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "User account is disabled")
+	}
+
+	res, err := h.issueTokens(ctx, tokenGrant{
+		client:    client,
+		user:      &user,
+		scopes:    authCode.Scopes,
+		nonce:     authCode.Nonce.String,
+		authTime:  authCode.AuthTime,
+		acr:       authCode.Acr.String,
+		amr:       authCode.Amr,
+		sessionID: authCode.SessionID,
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	return respondWithToken(c, res)
+}
+
+// refreshTokenGrant exchanges a refresh token for new tokens (RFC 6749 section 6).
+// Refresh tokens are single use: each exchange revokes the presented token and
+// issues a new one. Presenting a token that was already exchanged means it
+// leaked, so every token of that client and user is revoked.
+func (h *OIDCHandler) refreshTokenGrant(c echo.Context, client sqlc.Client, req *TokenRequest) error {
+	ctx := c.Request().Context()
+
+	if req.RefreshToken == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "refresh_token is required")
+	}
+
+	refreshToken, err := h.store.GetAnyOIDCRefreshTokenByToken(ctx, req.RefreshToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Refresh token is invalid")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	if refreshToken.ClientID != client.ClientID {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Refresh token was issued to another client")
+	}
+
+	if refreshToken.Revoked {
+		return h.rejectReusedRefreshToken(c, refreshToken)
+	}
+
+	if refreshToken.ExpiresAt.Before(time.Now()) {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Refresh token has expired")
+	}
+
+	// The token ends with the CentralAuth session it was issued from
+	active, err := h.sessionActive(ctx, refreshToken.SessionID)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if !active {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "The session of the refresh token has ended")
+	}
+
+	// The client may ask for fewer scopes than originally granted, never more (RFC 6749 section 6).
+	// Only the new access and ID tokens are narrowed; the new refresh token keeps the original scopes.
+	scopes := refreshToken.Scopes
+	if req.Scope != "" {
+		scopes = utils.ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(refreshToken.Scopes, scope) {
+				return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope was not granted to the refresh token: %s", scope))
+			}
+		}
+	}
+	if denied := unsupportedScopes(client, scopes); len(denied) > 0 {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope not allowed for this client: %s", strings.Join(denied, " ")))
+	}
+
+	user, err := h.store.GetUserById(ctx, refreshToken.UserID)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if !user.IsActive {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "User account is disabled")
+	}
+
+	res, err := h.issueTokens(ctx, tokenGrant{
+		client:              client,
+		user:                &user,
+		scopes:              scopes,
+		refreshScopes:       refreshToken.Scopes,
+		authTime:            refreshToken.AuthTime,
+		acr:                 refreshToken.Acr.String,
+		amr:                 refreshToken.Amr,
+		sessionID:           refreshToken.SessionID,
+		rotatedRefreshToken: refreshToken.Token,
+	})
+	if err != nil {
+		if errors.Is(err, errRefreshTokenReused) {
+			return h.rejectReusedRefreshToken(c, refreshToken)
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	return respondWithToken(c, res)
+}
+
+// rejectReusedRefreshToken revokes the token family of a refresh token that was
+// presented after it had been exchanged (OAuth 2.0 Security BCP section 4.14.2)
+func (h *OIDCHandler) rejectReusedRefreshToken(c echo.Context, refreshToken sqlc.OidcRefreshToken) error {
+	ctx := c.Request().Context()
+
+	log.Printf("OIDC refresh token reuse detected for client %s and user %d, revoking all their tokens", refreshToken.ClientID, refreshToken.UserID)
+	if err := h.revokeClientUserTokens(ctx, refreshToken.ClientID, refreshToken.UserID); err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "Refresh token has already been used")
+}
+
+// clientCredentialsGrant issues an access token to a confidential client acting
+// on its own behalf (RFC 6749 section 4.4). The token has the client as subject
+// and neither a refresh token nor an ID token is issued.
+func (h *OIDCHandler) clientCredentialsGrant(c echo.Context, client sqlc.Client, req *TokenRequest) error {
+	ctx := c.Request().Context()
+
+	if client.IsPublic {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorUnauthorizedClient, "Public clients cannot use the client_credentials grant")
+	}
+
+	// Without a scope parameter the client gets every scope it is allowed
+	// that does not describe an end-user
+	var scopes []string
+	if req.Scope == "" {
+		scopes = make([]string, 0, len(client.AllowedScopes))
+		for _, scope := range client.AllowedScopes {
+			if !slices.Contains(supportedScopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else {
+		scopes = utils.ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if slices.Contains(supportedScopes, scope) {
+				return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope requires an end-user: %s", scope))
+			}
+			if !slices.Contains(client.AllowedScopes, scope) {
+				return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope not allowed for this client: %s", scope))
+			}
+		}
+	}
+
+	res, err := h.issueTokens(ctx, tokenGrant{
+		client: client,
+		scopes: scopes,
+	})
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	return respondWithToken(c, res)
+}
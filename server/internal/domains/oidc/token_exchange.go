@@ -0,0 +1,249 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// tokenTypeAccessToken identifies access tokens in token exchange requests and responses
+// (RFC 8693 section 3). It is the only type exchanged and issued.
+const tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+// exchangedToken is a subject or actor token presented in a token exchange
+type exchangedToken struct {
+	clientID string        // Empty for tokens of a CentralAuth session
+	userID   sql.NullInt32 // Not set for tokens issued to a client on its own behalf
+	// subjectClientID is the subject of a token without a user; it differs from clientID
+	// when the token was exchanged by another client than the one it was issued to
+	subjectClientID string
+	// scopes limits what the token can be exchanged for; nil for tokens of a
+	// CentralAuth session, which are not restricted to scopes
+	scopes    []string
+	sessionID sql.NullInt32
+	// actor is the act claim of a token that was itself obtained through token exchange
+	actor *utils.ActorClaim
+}
+
+// subject returns the sub claim of the token: the user, or the client when there is none
+func (t exchangedToken) subject() string {
+	if !t.userID.Valid {
+		return t.subjectClientID
+	}
+	return strconv.Itoa(int(t.userID.Int32))
+}
+
+// tokenExchangeGrant exchanges an access token for one aimed at a downstream service
+// (RFC 8693). The client then calls that service on behalf of the subject of the token,
+// which the act claim of the issued token records. The client may only exchange into
+// the audiences it is registered for, and the scopes can only be narrowed.
+func (h *OIDCHandler) tokenExchangeGrant(c echo.Context, client sqlc.Client, req *TokenRequest) error {
+	ctx := c.Request().Context()
+
+	if client.IsPublic {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorUnauthorizedClient, "Public clients cannot exchange tokens")
+	}
+
+	if req.SubjectToken == "" || req.SubjectTokenType == "" {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "subject_token and subject_token_type are required")
+	}
+	if req.SubjectTokenType != tokenTypeAccessToken {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Only access tokens can be exchanged")
+	}
+	if (req.ActorToken == "") != (req.ActorTokenType == "") {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "actor_token and actor_token_type must be sent together")
+	}
+	if req.ActorTokenType != "" && req.ActorTokenType != tokenTypeAccessToken {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Only access tokens can be sent as actor_token")
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != tokenTypeAccessToken {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "Only access tokens can be requested")
+	}
+
+	audience, err := exchangeAudience(client, req)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	subject, err := h.resolveExchangedToken(ctx, req.SubjectToken)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if subject == nil {
+		return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "subject_token is invalid or has expired")
+	}
+
+	// The client acts for the subject, unless it presents the token of the party it acts for
+	actor := &utils.ActorClaim{Subject: client.ClientID, ClientID: client.ClientID}
+	if req.ActorToken != "" {
+		actorToken, err := h.resolveExchangedToken(ctx, req.ActorToken)
+		if err != nil {
+			return respondWithOAuthError(c, err)
+		}
+		if actorToken == nil {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "actor_token is invalid or has expired")
+		}
+		actor = &utils.ActorClaim{Subject: actorToken.subject(), ClientID: actorToken.clientID}
+	}
+	// Earlier actors of a token that was exchanged before are kept, nested (RFC 8693 section 4.1)
+	actor.Actor = subject.actor
+
+	// Without a scope parameter the token keeps the scopes of the subject token the client is allowed
+	var scopes []string
+	if req.Scope == "" {
+		scopes = make([]string, 0)
+		for _, scope := range clientScopes(client) {
+			if subject.scopes == nil || slices.Contains(subject.scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else {
+		scopes = utils.ParseScope(req.Scope)
+		for _, scope := range scopes {
+			if subject.scopes != nil && !slices.Contains(subject.scopes, scope) {
+				return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope was not granted to the subject token: %s", scope))
+			}
+		}
+		if denied := unsupportedScopes(client, scopes); len(denied) > 0 {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidScope, fmt.Sprintf("Scope not allowed for this client: %s", strings.Join(denied, " ")))
+		}
+	}
+
+	var user *sqlc.User
+	if subject.userID.Valid {
+		found, err := h.store.GetUserById(ctx, subject.userID.Int32)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "The user of the subject token no longer exists")
+			}
+			return respondWithOAuthError(c, err)
+		}
+		if !found.IsActive {
+			return utils.RespondWithOAuthError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidGrant, "User account is disabled")
+		}
+		user = &found
+	}
+
+	grant := tokenGrant{
+		client: client,
+		user:   user,
+		scopes: scopes,
+		// The exchanged token ends with the session of the subject token
+		sessionID: subject.sessionID,
+		audience:  audience,
+		actor:     actor,
+	}
+	// A token a client obtained on its own behalf keeps that client as subject
+	if user == nil {
+		grant.subjectClientID = subject.subject()
+	}
+
+	res, err := h.issueTokens(ctx, grant)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+
+	res.IssuedTokenType = tokenTypeAccessToken
+	return respondWithToken(c, res)
+}
+
+// exchangeAudience returns the audiences and resources a token exchange asks for.
+// At least one is required, and each must be registered for the client.
+func exchangeAudience(client sqlc.Client, req *TokenRequest) ([]string, error) {
+	invalidTarget := func(format string, args ...any) error {
+		return newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidTarget, fmt.Sprintf(format, args...))
+	}
+
+	// Resources are absolute URIs without a fragment (RFC 8707 section 2)
+	for _, resource := range req.Resource {
+		u, err := url.Parse(resource)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return nil, invalidTarget("Invalid resource: %s", resource)
+		}
+	}
+
+	audience := make([]string, 0, len(req.Audience)+len(req.Resource))
+	for _, target := range slices.Concat(req.Audience, req.Resource) {
+		if target == "" || slices.Contains(audience, target) {
+			continue
+		}
+		if !slices.Contains(client.TokenExchangeAudiences, target) {
+			return nil, invalidTarget("Client may not exchange tokens for: %s", target)
+		}
+		audience = append(audience, target)
+	}
+	if len(audience) == 0 {
+		return nil, newOAuthError(utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, "audience or resource is required")
+	}
+	return audience, nil
+}
+
+// resolveExchangedToken looks an access token up in the token tables. Tokens issued by the
+// token endpoint and tokens of a CentralAuth session are both accepted. It returns nil
+// when the token is unknown, expired or revoked, or when its session has ended.
+func (h *OIDCHandler) resolveExchangedToken(ctx context.Context, token string) (*exchangedToken, error) {
+	oidcToken, err := h.store.GetOIDCAccessTokenByToken(ctx, token)
+	if err == nil {
+		active, err := h.sessionActive(ctx, oidcToken.SessionID)
+		if err != nil || !active {
+			return nil, err
+		}
+		actor, err := storedActor(oidcToken.Actor)
+		if err != nil {
+			return nil, err
+		}
+		subjectClientID := oidcToken.ClientID
+		if oidcToken.SubjectClientID.Valid {
+			subjectClientID = oidcToken.SubjectClientID.String
+		}
+		return &exchangedToken{
+			clientID:        oidcToken.ClientID,
+			userID:          oidcToken.UserID,
+			subjectClientID: subjectClientID,
+			scopes:          oidcToken.Scopes,
+			sessionID:       oidcToken.SessionID,
+			actor:           actor,
+		}, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// The query only returns tokens whose session is still active
+	sessionToken, err := h.store.GetAccessTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if sessionToken.ExpiresAt.Before(time.Now()) {
+		return nil, nil
+	}
+	return &exchangedToken{
+		userID:    sql.NullInt32{Int32: sessionToken.UserID, Valid: true},
+		sessionID: sql.NullInt32{Int32: sessionToken.SessionID, Valid: true},
+	}, nil
+}
+
+// storedActor decodes the act claim stored with an access token, if it has one
+func storedActor(actor sql.NullString) (*utils.ActorClaim, error) {
+	if !actor.Valid {
+		return nil, nil
+	}
+	claim := new(utils.ActorClaim)
+	if err := json.Unmarshal([]byte(actor.String), claim); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
@@ -0,0 +1,233 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// errRefreshTokenReused is returned by issueTokens when the refresh token being
+// rotated was used by a concurrent request first
+var errRefreshTokenReused = errors.New("refresh token has already been used")
+
+// tokenGrant describes what a successful grant authorizes
+type tokenGrant struct {
+	client sqlc.Client
+	user   *sqlc.User // nil for tokens issued to the client itself
+	scopes []string
+	nonce  string
+	// refreshScopes is the scope of the refresh token when it differs from
+	// scopes: a refresh that narrows the access token keeps the original
+	// scope for later refreshes (RFC 6749 section 6)
+	refreshScopes []string
+	authTime      time.Time
+	// acr and amr describe how the user signed in to the session
+	acr string
+	amr []string
+	// sessionID is the CentralAuth session the grant was issued from; the
+	// tokens become inactive when that session ends
+	sessionID sql.NullInt32
+	// rotatedRefreshToken is the refresh token exchanged by this grant; it is
+	// revoked in the same transaction that stores the new tokens
+	rotatedRefreshToken string
+	// audience restricts the access token to the services it is intended for
+	audience []string
+	// actor is set for tokens obtained through token exchange, which are
+	// delegated access tokens only, without a refresh or ID token
+	actor *utils.ActorClaim
+	// subjectClientID is the subject when a client exchanges a token another
+	// client obtained on its own behalf; the token is still held by client
+	subjectClientID string
+}
+
+// subject returns the sub claim of a grant: the user, or the client when there is none
+func (g tokenGrant) subject() string {
+	if g.user != nil {
+		return strconv.Itoa(int(g.user.ID))
+	}
+	if g.subjectClientID != "" {
+		return g.subjectClientID
+	}
+	return g.client.ClientID
+}
+
+// userID returns the user of a grant as a nullable column value
+func (g tokenGrant) userID() sql.NullInt32 {
+	if g.user == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: g.user.ID, Valid: true}
+}
+
+// issueTokens creates and stores the tokens for a grant.
+// A refresh token is only issued for a user when the client may use the
+// refresh_token grant, and an ID token only when the openid scope was granted.
+// Neither is issued with a token obtained through token exchange.
+func (h *OIDCHandler) issueTokens(ctx context.Context, grant tokenGrant) (*TokenResponse, error) {
+	scope := strings.Join(grant.scopes, " ")
+
+	// Without a requested audience the token is for the endpoints of this server, such as UserInfo
+	audience := grant.audience
+	if len(audience) == 0 {
+		audience = []string{h.config.OIDC.Issuer}
+	}
+
+	accessToken, expiresAt, err := utils.CreateOAuthAccessToken(utils.OAuthAccessTokenClaims{
+		ClientID: grant.client.ClientID,
+		Scope:    scope,
+		Actor:    grant.actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   h.config.OIDC.Issuer,
+			Subject:  grant.subject(),
+			Audience: audience,
+		},
+	}, h.config.OIDC.AccessTokenExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	var actor sql.NullString
+	if grant.actor != nil {
+		encoded, err := json.Marshal(grant.actor)
+		if err != nil {
+			return nil, err
+		}
+		actor = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	res := &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(expiresAt).Seconds()),
+		Scope:       scope,
+	}
+
+	if grant.user != nil && grant.actor == nil && slices.Contains(grant.scopes, scopeOpenID) {
+		idToken, err := h.createIDToken(grant, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		res.IDToken = idToken
+	}
+
+	err = h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if grant.rotatedRefreshToken != "" {
+			if _, err := q.RotateOIDCRefreshToken(ctx, grant.rotatedRefreshToken); err != nil {
+				if err == sql.ErrNoRows {
+					return errRefreshTokenReused
+				}
+				return err
+			}
+		}
+
+		storedAccessToken, err := q.CreateOIDCAccessToken(ctx, sqlc.CreateOIDCAccessTokenParams{
+			Token:     accessToken,
+			ClientID:  grant.client.ClientID,
+			UserID:    grant.userID(),
+			ExpiresAt: expiresAt,
+			Scopes:    grant.scopes,
+			SessionID: grant.sessionID,
+			Audience:  audience,
+			Actor:     actor,
+			SubjectClientID: sql.NullString{
+				String: grant.subjectClientID,
+				Valid:  grant.subjectClientID != "",
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if grant.user == nil || grant.actor != nil || !slices.Contains(clientGrantTypes(grant.client), grantTypeRefreshToken) {
+			return nil
+		}
+
+		refreshToken, err := utils.GenerateSecureToken(32)
+		if err != nil {
+			return err
+		}
+		refreshScopes := grant.refreshScopes
+		if refreshScopes == nil {
+			refreshScopes = grant.scopes
+		}
+		_, err = q.CreateOIDCRefreshToken(ctx, sqlc.CreateOIDCRefreshTokenParams{
+			Token:         refreshToken,
+			ClientID:      grant.client.ClientID,
+			UserID:        grant.user.ID,
+			AccessTokenID: storedAccessToken.ID,
+			ExpiresAt:     time.Now().Add(h.config.OIDC.RefreshTokenExpiry),
+			Scopes:        refreshScopes,
+			AuthTime:      grant.authTime,
+			SessionID:     grant.sessionID,
+			Acr:           sql.NullString{String: grant.acr, Valid: grant.acr != ""},
+			Amr:           grant.amr,
+		})
+		if err != nil {
+			return err
+		}
+		res.RefreshToken = refreshToken
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// revokeClientUserTokens revokes every OIDC token a client holds for a user
+func (h *OIDCHandler) revokeClientUserTokens(ctx context.Context, clientID string, userID int32) error {
+	return h.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.RevokeAllClientUserRefreshTokens(ctx, sqlc.RevokeAllClientUserRefreshTokensParams{
+			ClientID: clientID,
+			UserID:   userID,
+		}); err != nil {
+			return err
+		}
+		return q.RevokeAllClientUserAccessTokens(ctx, sqlc.RevokeAllClientUserAccessTokensParams{
+			ClientID: clientID,
+			UserID:   sql.NullInt32{Int32: userID, Valid: true},
+		})
+	})
+}
+
+// createIDToken signs an ID token for the grant, bound to the access token through at_hash
+func (h *OIDCHandler) createIDToken(grant tokenGrant, accessToken string) (string, error) {
+	claims := utils.IDTokenClaims{
+		Nonce:           grant.nonce,
+		AuthorizedParty: grant.client.ClientID,
+		StandardClaims:  standardClaims(*grant.user, grant.scopes),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   h.config.OIDC.Issuer,
+			Subject:  grant.subject(),
+			Audience: jwt.ClaimStrings{grant.client.ClientID},
+		},
+	}
+	if !grant.authTime.IsZero() {
+		claims.AuthTime = grant.authTime.Unix()
+	}
+	if grant.sessionID.Valid {
+		claims.SessionID = strconv.Itoa(int(grant.sessionID.Int32))
+	}
+	claims.ACR = grant.acr
+	claims.AMR = grant.amr
+
+	return utils.CreateIDToken(claims, accessToken, h.config.OIDC.IDTokenExpiry)
+}
+
+// respondWithToken writes a token response with the caching headers required by RFC 6749
+func respondWithToken(c echo.Context, res *TokenResponse) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+	return c.JSON(int(utils.StatusCodeSuccess), res)
+}
@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// Userinfo handles the OpenID Connect UserInfo endpoint (GET and POST /oauth2/userinfo).
+// Only the claims covered by the scopes granted to the access token are returned.
+func (h *OIDCHandler) Userinfo(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	token, err := bearerToken(c)
+	if err != nil {
+		return utils.RespondWithBearerError(c, utils.StatusCodeBadRequest, utils.OAuthErrorInvalidRequest, err.Error())
+	}
+	if token == "" {
+		return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, "", "")
+	}
+
+	accessToken, err := h.store.GetOIDCAccessTokenByToken(ctx, token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The access token is invalid or has expired")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	// The token ends with the CentralAuth session it was issued from
+	active, err := h.sessionActive(ctx, accessToken.SessionID)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	if !active {
+		return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The session of the access token has ended")
+	}
+
+	if !slices.Contains(accessToken.Scopes, scopeOpenID) {
+		return utils.RespondWithBearerError(c, utils.StatusCodeForbidden, utils.OAuthErrorInsufficientScope, "The access token was not granted the openid scope")
+	}
+
+	if !accessToken.UserID.Valid {
+		return utils.RespondWithBearerError(c, utils.StatusCodeForbidden, utils.OAuthErrorInsufficientScope, "The access token was not issued for an end-user")
+	}
+
+	user, err := h.store.GetUserById(ctx, accessToken.UserID.Int32)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The user of the access token no longer exists")
+		}
+		return respondWithOAuthError(c, err)
+	}
+	if !user.IsActive {
+		return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "User account is disabled")
+	}
+
+	claims := utils.UserInfoClaims{
+		StandardClaims: standardClaims(user, accessToken.Scopes),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: strconv.Itoa(int(user.ID)),
+		},
+	}
+
+	client, err := h.store.GetClientWithOIDCSettings(ctx, accessToken.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.RespondWithBearerError(c, utils.StatusCodeUnauthorized, utils.OAuthErrorInvalidToken, "The client of the access token is no longer enabled")
+		}
+		return respondWithOAuthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	if !client.UserinfoSignedResponseAlg.Valid {
+		return c.JSON(int(utils.StatusCodeSuccess), claims)
+	}
+
+	// The client registered for signed responses (OpenID Connect Core section 5.3.2),
+	// which are only signed with the algorithm it registered
+	claims.Issuer = h.config.OIDC.Issuer
+	claims.Audience = jwt.ClaimStrings{client.ClientID}
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	signed, err := utils.SignTokenWithAlgorithm(claims, client.UserinfoSignedResponseAlg.String)
+	if err != nil {
+		return respondWithOAuthError(c, err)
+	}
+	return c.Blob(int(utils.StatusCodeSuccess), "application/jwt", []byte(signed))
+}
+
+// bearerToken reads the access token from the Authorization header or, for
+// form-encoded POST requests, the access_token body parameter (RFC 6750 section 2).
+// It returns an empty token when none was presented.
+func bearerToken(c echo.Context) (string, error) {
+	var token string
+
+	if header := c.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+		scheme, value, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return "", errors.New("Authorization header must use the Bearer scheme")
+		}
+		token = strings.TrimSpace(value)
+	}
+
+	if c.Request().Method == "POST" && strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm) {
+		if formToken := c.FormValue("access_token"); formToken != "" {
+			if token != "" {
+				return "", errors.New("The access token must not be sent using more than one method")
+			}
+			token = formToken
+		}
+	}
+
+	return token, nil
+}
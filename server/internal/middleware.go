@@ -1,8 +1,6 @@
 package internal
 
 import (
-	"net/http"
-
 	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/middlewares"
 	middleware "github.com/Satishcg12/CentralAuthV2/server/internal/middlewares"
@@ -13,13 +11,7 @@ import (
 func SetupGlobalMiddleware(e *echo.Echo, cfg *config.Config, cm middlewares.IMiddleware) {
 
 	// Add CORS middleware
-	e.Use(emiddleware.CORSWithConfig(emiddleware.CORSConfig{
-		AllowOrigins:     []string{cfg.ClientURL},
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		AllowCredentials: true,
-		MaxAge:           86400, // 24 hours
-	}))
+	e.Use(cm.CORSMiddleware())
 
 	// Add other middleware
 	e.Use(emiddleware.LoggerWithConfig(emiddleware.LoggerConfig{
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
 	"github.com/labstack/echo/v4"
@@ -36,3 +37,27 @@ func (m *Middleware) RequireAuthMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// RequireAdminMiddleware blocks requests from users other than the configured admin
+// It requires RequireAuthMiddleware to be executed before this middleware
+func (m *Middleware) RequireAdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, _ := c.Get("user_id").(int64)
+
+			user, err := m.Store.GetUserById(c.Request().Context(), int32(userID))
+			if err != nil || !strings.EqualFold(user.Email, m.Config.AdminEmail) {
+				return utils.RespondWithError(
+					c,
+					http.StatusForbidden,
+					"Access denied",
+					utils.ErrorCodeForbidden,
+					"Administrator privileges are required",
+					nil,
+				)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	emiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// providerPathPrefixes are the OAuth 2.0 / OpenID Connect provider endpoints,
+// which the browser apps of relying parties call from their own origins
+var providerPathPrefixes = []string{"/oauth2/", "/.well-known/"}
+
+// CORSMiddleware lets the CentralAuth frontend call the API with credentials, and
+// the web origins registered by clients call the provider endpoints without them
+func (m *Middleware) CORSMiddleware() echo.MiddlewareFunc {
+	app := emiddleware.CORSWithConfig(emiddleware.CORSConfig{
+		AllowOrigins:     []string{m.Config.ClientURL},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowCredentials: true,
+		MaxAge:           86400, // 24 hours
+	})
+	provider := emiddleware.CORSWithConfig(emiddleware.CORSConfig{
+		AllowOriginFunc: m.isAllowedOrigin,
+		AllowMethods:    []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowHeaders:    []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		MaxAge:          600, // Origins change when clients are updated
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		appNext := app(next)
+		providerNext := provider(next)
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			for _, prefix := range providerPathPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return providerNext(c)
+				}
+			}
+			return appNext(c)
+		}
+	}
+}
+
+// isAllowedOrigin reports whether the origin is the frontend or registered by a client
+func (m *Middleware) isAllowedOrigin(origin string) (bool, error) {
+	if origin == m.Config.ClientURL {
+		return true, nil
+	}
+	return m.Store.IsClientAllowedOrigin(context.Background(), origin)
+}
@@ -9,9 +9,11 @@ import (
 // IMiddleware defines the interface for middleware operations
 type IMiddleware interface {
 	// Add middleware methods here
+	CORSMiddleware() echo.MiddlewareFunc
 	TokenLoaderMiddleware() echo.MiddlewareFunc
 	ValidateAccessTokenMiddleware() echo.MiddlewareFunc
 	RequireAuthMiddleware() echo.MiddlewareFunc
+	RequireAdminMiddleware() echo.MiddlewareFunc
 }
 
 type Middleware struct {
@@ -1,6 +1,8 @@
 package middlewares
 
 import (
+	"time"
+
 	"github.com/Satishcg12/CentralAuthV2/server/internal/utils"
 	"github.com/labstack/echo/v4"
 )
@@ -65,6 +67,14 @@ func (m *Middleware) ValidateAccessTokenMiddleware() echo.MiddlewareFunc {
 				return next(c)
 			}
 
+			// Only tokens stored for an active session are accepted, so tokens of other
+			// kinds signed with the same keys and tokens of ended sessions are rejected
+			storedToken, err := m.Store.GetAccessTokenByToken(c.Request().Context(), accessToken)
+			if err != nil || storedToken.ExpiresAt.Before(time.Now()) || int64(storedToken.UserID) != claims.UserID {
+				c.Set("auth_error", "Access token is not valid for an active session")
+				return next(c)
+			}
+
 			// Token is valid - set authentication data in context
 			c.Set("user_id", claims.UserID)
 			c.Set("authenticated", true)
@@ -6,7 +6,10 @@ import (
 	"github.com/Satishcg12/CentralAuthV2/server/internal/domains"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/auth"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/client"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/consent"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/health"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/keys"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/domains/oidc"
 	"github.com/Satishcg12/CentralAuthV2/server/internal/middlewares"
 	"github.com/labstack/echo/v4"
 )
@@ -22,6 +25,9 @@ func SetupRoutes(e *echo.Echo, store *db.Store, cfg *config.Config, cm middlewar
 	// Create handlers
 	authHandler := auth.NewAuthHandler(ah)
 	clientHandler := client.NewClientHandler(ah)
+	oidcHandler := oidc.NewOIDCHandler(ah)
+	keysHandler := keys.NewKeysHandler(ah)
+	consentHandler := consent.NewConsentHandler(ah)
 	// userHandler := handlers.NewUserHandler(ah)
 	// roleHandler := handlers.NewRoleHandler(ah)
 	// permissionHandler := handlers.NewPermissionHandler(ah)
@@ -60,6 +66,8 @@ func SetupRoutes(e *echo.Echo, store *db.Store, cfg *config.Config, cm middlewar
 	// clientRead.Use(cm.RequirePermission("client_read"))
 	clientRead.GET("", clientHandler.GetAll)
 	clientRead.GET("/:id", clientHandler.GetByID)
+	clientRead.GET("/:id/backchannel-logout-deliveries", clientHandler.GetBackchannelLogoutDeliveries)
+	clientRead.GET("/:id/secrets", clientHandler.GetSecrets)
 
 	// Routes that require client_write permission
 	clientWrite := clients.Group("")
@@ -68,10 +76,49 @@ func SetupRoutes(e *echo.Echo, store *db.Store, cfg *config.Config, cm middlewar
 	clientWrite.PUT("/:id", clientHandler.Update)
 	clientWrite.DELETE("/:id", clientHandler.Delete)
 	clientWrite.POST("/:id/regenerate-secret", clientHandler.RegenerateSecret)
+	clientWrite.DELETE("/:id/secrets/:secret_id", clientHandler.RevokeSecret)
 
 	// New route to handle regenerating client secret by client_id (UUID)
 	clientWrite.POST("/regenerate-secret/:client_id", clientHandler.RegenerateSecretByClientID)
 
+	// Signing key routes - require the administrator
+	signingKeys := v1.Group("/keys")
+	signingKeys.Use(cm.RequireAuthMiddleware(), cm.RequireAdminMiddleware())
+	signingKeys.GET("", keysHandler.GetAll)
+	signingKeys.POST("/rotate", keysHandler.Rotate)
+	signingKeys.POST("/:kid/retire", keysHandler.Retire)
+
+	// Consent routes - the current user's grants to third-party clients
+	consents := v1.Group("/consents")
+	consents.Use(cm.RequireAuthMiddleware())
+	consents.GET("", consentHandler.GetAll)
+	consents.DELETE("/:client_id", consentHandler.Revoke)
+
+	// OAuth 2.0 / OpenID Connect provider routes - public, mounted outside /api/v1
+	// because relying parties expect them at well-known locations
+	oauth := e.Group("/oauth2")
+	oauth.GET("/authorize", oidcHandler.Authorize)
+	oauth.POST("/authorize", oidcHandler.Authorize)
+	oauth.POST("/consent", oidcHandler.Consent)
+	oauth.POST("/par", oidcHandler.PushedAuthorization)
+	oauth.POST("/token", oidcHandler.Token)
+	oauth.POST("/device_authorization", oidcHandler.DeviceAuthorization)
+	oauth.GET("/device", oidcHandler.DeviceVerification)
+	oauth.POST("/device", oidcHandler.DeviceVerification)
+	oauth.GET("/userinfo", oidcHandler.Userinfo)
+	oauth.POST("/userinfo", oidcHandler.Userinfo)
+	oauth.POST("/introspect", oidcHandler.Introspect)
+	oauth.POST("/revoke", oidcHandler.Revoke)
+	oauth.GET("/logout", oidcHandler.EndSession)
+	oauth.POST("/logout", oidcHandler.EndSession)
+	oauth.POST("/register", oidcHandler.Register)
+	oauth.GET("/register/:client_id", oidcHandler.GetRegistration)
+	oauth.PUT("/register/:client_id", oidcHandler.UpdateRegistration)
+	oauth.DELETE("/register/:client_id", oidcHandler.DeleteRegistration)
+
+	e.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+	e.GET("/.well-known/jwks.json", oidcHandler.JWKS)
+
 	// // User routes - most require authentication
 	// users := v1.Group("/users")
 
@@ -31,8 +31,15 @@ func InitializeServer(cfg *config.Config) *Server {
 	// Set up the validator using the one defined in utils package
 	e.Validator = utils.NewValidator()
 
-	// Initialize JWT configuration
-	utils.InitJWT(cfg.JWT)
+	// Initialize JWT configuration and signing keys
+	if err := utils.InitJWT(cfg.JWT); err != nil {
+		log.Fatalf("Failed to initialize JWT signing key: %v", err)
+	}
+
+	// Load the key clients may encrypt request objects with
+	if err := utils.InitRequestObjectEncryption(cfg.OIDC); err != nil {
+		log.Fatalf("Failed to initialize request object encryption key: %v", err)
+	}
 
 	// Connect to database
 	database, err := db.Connect(cfg.DB)
@@ -50,6 +57,15 @@ func InitializeServer(cfg *config.Config) *Server {
 	// Create store
 	store := db.NewStore(database)
 
+	// Load the signing keys shared by all replicas and start rotating them
+	// Replicas must not sign with keys the others do not know, so this is fatal
+	if err := utils.InitKeyRotation(context.Background(), store, cfg); err != nil {
+		log.Fatalf("Failed to initialize signing key rotation: %v", err)
+	}
+
+	// Notify relying parties through back-channel logout when sessions end
+	utils.InitBackchannelLogout(store, cfg)
+
 	// Add store to context
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
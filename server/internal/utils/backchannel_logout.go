@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// BackchannelLogoutEvent is the event a logout token carries (OpenID Connect Back-Channel Logout 1.0 section 2.4)
+const BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// Back-channel logout delivery states (backchannel_logout_deliveries.status)
+const (
+	DeliveryStatePending   = "pending"   // Waiting for its next attempt
+	DeliveryStateDelivered = "delivered" // Accepted by the client
+	DeliveryStateFailed    = "failed"    // Given up after the maximum number of attempts
+)
+
+const (
+	// backchannelLogoutPollInterval is how often due deliveries are looked for,
+	// which picks up retries and deliveries queued by other replicas
+	backchannelLogoutPollInterval = 10 * time.Second
+	// backchannelLogoutBatchSize is the number of deliveries sent per poll
+	backchannelLogoutBatchSize = 50
+	// logoutTokenExpiry is the lifetime of a logout token, which is signed anew for every attempt
+	logoutTokenExpiry = 2 * time.Minute
+)
+
+// LogoutTokenClaims represents the claims of a logout token.
+// At least one of sub and sid is set; a logout token never carries a nonce.
+type LogoutTokenClaims struct {
+	Events    map[string]struct{} `json:"events"`
+	SessionID string              `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// CreateLogoutToken signs a logout token. The caller sets issuer, subject,
+// audience and token ID; expiry and issue time are filled in here.
+func CreateLogoutToken(claims LogoutTokenClaims) (string, error) {
+	now := time.Now()
+	claims.Events = map[string]struct{}{BackchannelLogoutEvent: {}}
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(logoutTokenExpiry))
+	claims.IssuedAt = jwt.NewNumericDate(now)
+
+	key, err := keyStore.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+	return signTokenWithKey(key, claims, "logout+jwt")
+}
+
+// BackchannelLogout notifies clients that registered a backchannel_logout_uri when
+// a session they hold tokens for ends. Deliveries are queued in the database so
+// that they survive restarts, and failed ones are retried with exponential backoff.
+type BackchannelLogout struct {
+	store       *db.Store
+	issuer      string
+	httpClient  *http.Client
+	backoff     time.Duration
+	maxAttempts int
+	wake        chan struct{}
+}
+
+// InitBackchannelLogout registers the session end hook and starts delivering logout tokens
+func InitBackchannelLogout(store *db.Store, cfg *config.Config) {
+	b := &BackchannelLogout{
+		store:  store,
+		issuer: cfg.OIDC.Issuer,
+		httpClient: &http.Client{
+			Timeout: cfg.OIDC.BackchannelLogoutTimeout,
+			// A logout endpoint must answer directly, redirects are not followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		backoff:     cfg.OIDC.BackchannelLogoutBackoff,
+		maxAttempts: cfg.OIDC.BackchannelLogoutMaxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+
+	store.OnSessionEnd(b.enqueue)
+	go b.run()
+}
+
+// enqueue queues a logout token for every client holding tokens of the ended session,
+// or of any session of the user when sessionID is not valid
+func (b *BackchannelLogout) enqueue(ctx context.Context, userID int32, sessionID sql.NullInt32) {
+	clients, err := b.store.ListBackchannelLogoutClients(ctx, sqlc.ListBackchannelLogoutClientsParams{
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		log.Printf("Failed to find clients to notify of the logout of user %d: %v", userID, err)
+		return
+	}
+
+	for _, client := range clients {
+		_, err := b.store.CreateBackchannelLogoutDelivery(ctx, sqlc.CreateBackchannelLogoutDeliveryParams{
+			ClientID:  client.ClientID,
+			LogoutUri: client.BackchannelLogoutUri.String,
+			UserID:    userID,
+			SessionID: sessionID,
+			Jti:       uuid.New().String(),
+		})
+		if err != nil {
+			log.Printf("Failed to queue back-channel logout for client %s: %v", client.ClientID, err)
+		}
+	}
+
+	if len(clients) > 0 {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run sends due deliveries when new ones are queued and on every poll
+func (b *BackchannelLogout) run() {
+	ticker := time.NewTicker(backchannelLogoutPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		b.deliverDue()
+	}
+}
+
+// deliverDue leases the due deliveries and sends them concurrently
+func (b *BackchannelLogout) deliverDue() {
+	// The lease outlasts every request of the batch, after which an
+	// unfinished delivery (e.g. of a replica that crashed) is due again
+	lease := b.httpClient.Timeout + time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), lease)
+	defer cancel()
+
+	deliveries, err := b.store.ClaimDueBackchannelLogoutDeliveries(ctx, sqlc.ClaimDueBackchannelLogoutDeliveriesParams{
+		LeaseUntil: time.Now().Add(lease),
+		BatchSize:  backchannelLogoutBatchSize,
+	})
+	if err != nil {
+		log.Printf("Failed to load back-channel logout deliveries: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends one logout token and records the outcome
+func (b *BackchannelLogout) deliver(ctx context.Context, delivery sqlc.BackchannelLogoutDelivery) {
+	sendErr := b.send(ctx, delivery)
+	if sendErr == nil {
+		if err := b.store.MarkBackchannelLogoutDelivered(ctx, delivery.ID); err != nil {
+			log.Printf("Failed to record back-channel logout delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	attempts := int(delivery.Attempts) + 1
+	status := DeliveryStatePending
+	if attempts >= b.maxAttempts {
+		status = DeliveryStateFailed
+		log.Printf("Back-channel logout to client %s failed %d times, giving up: %v", delivery.ClientID, attempts, sendErr)
+	} else {
+		log.Printf("Back-channel logout to client %s failed (attempt %d): %v", delivery.ClientID, attempts, sendErr)
+	}
+
+	err := b.store.RecordBackchannelLogoutFailure(ctx, sqlc.RecordBackchannelLogoutFailureParams{
+		ID:            delivery.ID,
+		Status:        status,
+		LastError:     sql.NullString{String: sendErr.Error(), Valid: true},
+		NextAttemptAt: time.Now().Add(b.backoff << (attempts - 1)),
+	})
+	if err != nil {
+		log.Printf("Failed to record back-channel logout delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send POSTs the logout token to the client (OpenID Connect Back-Channel Logout 1.0 section 2.5)
+func (b *BackchannelLogout) send(ctx context.Context, delivery sqlc.BackchannelLogoutDelivery) error {
+	claims := LogoutTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   b.issuer,
+			Subject:  strconv.Itoa(int(delivery.UserID)),
+			Audience: jwt.ClaimStrings{delivery.ClientID},
+			ID:       delivery.Jti,
+		},
+	}
+	if delivery.SessionID.Valid {
+		claims.SessionID = strconv.Itoa(int(delivery.SessionID.Int32))
+	}
+	logoutToken, err := CreateLogoutToken(claims)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("logout_token", logoutToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.LogoutUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("logout endpoint responded with %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ClientAuthentication holds how a client authenticates at the token endpoint
+type ClientAuthentication struct {
+	Method  string
+	JWKS    sql.NullString
+	JWKSURI sql.NullString
+}
+
+// ResolveClientAuthentication checks the authentication method requested for a client
+// along with its keys. Public clients cannot authenticate and always use none. Keys are
+// required for private_key_jwt, and any client may register them to sign request objects.
+// A registered key set is stored with its public members only.
+func ResolveClientAuthentication(isPublic bool, method, jwks, jwksURI string) (ClientAuthentication, error) {
+	if isPublic {
+		if method != "" && method != AuthMethodNone {
+			return ClientAuthentication{}, errors.New("public clients cannot authenticate at the token endpoint")
+		}
+		method = AuthMethodNone
+	}
+
+	if method == "" {
+		method = AuthMethodClientSecretBasic
+	}
+	if jwks != "" && jwksURI != "" {
+		return ClientAuthentication{}, errors.New("jwks and jwks_uri cannot both be registered")
+	}
+	if jwks == "" && jwksURI == "" {
+		if method == AuthMethodPrivateKeyJWT {
+			return ClientAuthentication{}, errors.New("private_key_jwt requires either jwks or jwks_uri")
+		}
+		return ClientAuthentication{Method: method}, nil
+	}
+	if jwksURI != "" {
+		return ClientAuthentication{
+			Method:  method,
+			JWKSURI: sql.NullString{String: jwksURI, Valid: true},
+		}, nil
+	}
+
+	var keySet JWKS
+	if err := json.Unmarshal([]byte(jwks), &keySet); err != nil {
+		return ClientAuthentication{}, fmt.Errorf("jwks is not a valid JWK Set: %w", err)
+	}
+	if len(keySet.Keys) == 0 {
+		return ClientAuthentication{}, errors.New("jwks must contain at least one key")
+	}
+	for i, key := range keySet.Keys {
+		if _, err := key.PublicKey(); err != nil {
+			return ClientAuthentication{}, fmt.Errorf("key %d of jwks cannot be used: %w", i, err)
+		}
+	}
+	normalized, err := json.Marshal(keySet)
+	if err != nil {
+		return ClientAuthentication{}, err
+	}
+	return ClientAuthentication{
+		Method: method,
+		JWKS:   sql.NullString{String: string(normalized), Valid: true},
+	}, nil
+}
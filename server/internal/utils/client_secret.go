@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+)
+
+// Client secrets are stored as sha256$<hex salt>$<hex digest of salt || secret>.
+// Unlike passwords they are random values with plenty of entropy, so a slow key
+// derivation adds nothing, while the token endpoints verify a secret on every request.
+// The clients migration that hashed the existing plaintext secrets uses the same format.
+const (
+	clientSecretHashScheme = "sha256"
+	clientSecretSaltLength = 16
+	clientSecretLength     = 32
+)
+
+// GenerateClientSecret creates a new client secret and the hash to store for it.
+// The secret itself is only ever shown to the caller once.
+func GenerateClientSecret() (secret string, hash string, err error) {
+	secret, err = GenerateSecureToken(clientSecretLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	hash, err = HashClientSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hash, nil
+}
+
+// HashClientSecret hashes a client secret with a random salt
+func HashClientSecret(secret string) (string, error) {
+	salt := make([]byte, clientSecretSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("error generating salt: %w", err)
+	}
+
+	return clientSecretHashScheme + "$" + hex.EncodeToString(salt) + "$" + hex.EncodeToString(clientSecretDigest(salt, secret)), nil
+}
+
+// VerifyClientSecret reports whether the secret matches the stored hash.
+// The digests are compared in constant time.
+func VerifyClientSecret(hash, secret string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 3 || parts[0] != clientSecretHashScheme {
+		return false
+	}
+
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(clientSecretDigest(salt, secret), expected) == 1
+}
+
+// ClientSecretEncryptionKey derives the key encrypting the copies of client secrets that
+// client_secret_jwt needs to verify assertions. It comes from the same passphrase as the
+// key encrypting signing keys, so a leak of the database alone does not reveal the secrets.
+func ClientSecretEncryptionKey(cfg *config.Config) []byte {
+	passphrase := cfg.JWT.KeyEncryptionKey
+	if passphrase == "" {
+		passphrase = cfg.JWT.Secret
+	}
+	return DeriveEncryptionKey("client-secrets:" + passphrase)
+}
+
+func clientSecretDigest(salt []byte, secret string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
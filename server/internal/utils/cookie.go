@@ -7,6 +7,15 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// OPSessionCookie holds the opaque identifier of the CentralAuth session at the OpenID
+// provider endpoints. The token cookies are SameSite=Strict and not sent on the top-level
+// navigations relying parties start at /oauth2/authorize and /oauth2/logout; this one is
+// SameSite=Lax so it is. It is no credential: only those endpoints look the session up by it.
+const OPSessionCookie = "op_session"
+
+// opSessionCookiePath limits the OPSessionCookie to the OpenID provider endpoints
+const opSessionCookiePath = "/oauth2"
+
 // Cookie utility functions
 
 func SetCookie(c echo.Context, name, value string, maxAge int) error {
@@ -58,5 +67,23 @@ func DeleteTokensCookies(c echo.Context) error {
 	if err := DeleteCookie(c, "access_token"); err != nil {
 		return err
 	}
-	return DeleteCookie(c, "refresh_token")
+	if err := DeleteCookie(c, "refresh_token"); err != nil {
+		return err
+	}
+	return SetOPSessionCookie(c, "", -1)
+}
+
+// SetOPSessionCookie sets the OPSessionCookie to the identifier of the session, or deletes it when maxAge is negative
+func SetOPSessionCookie(c echo.Context, identifier string, maxAge int) error {
+	cookie := &http.Cookie{
+		Name:     OPSessionCookie,
+		Value:    identifier,
+		Path:     opSessionCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(c.Response().Writer, cookie)
+	return nil
 }
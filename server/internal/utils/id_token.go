@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StandardClaims are the OpenID Connect standard claims about the end-user
+// (OpenID Connect Core section 5.1). Which ones are set depends on the granted scopes.
+type StandardClaims struct {
+	Name                string `json:"name,omitempty"`
+	GivenName           string `json:"given_name,omitempty"`
+	FamilyName          string `json:"family_name,omitempty"`
+	PreferredUsername   string `json:"preferred_username,omitempty"`
+	UpdatedAt           int64  `json:"updated_at,omitempty"`
+	Email               string `json:"email,omitempty"`
+	EmailVerified       *bool  `json:"email_verified,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty"`
+	PhoneNumberVerified *bool  `json:"phone_number_verified,omitempty"`
+}
+
+// Authentication methods a session can be signed in with, reported in the amr claim (RFC 8176 section 2)
+const (
+	AMRPassword    = "pwd" // Password
+	AMRMultiFactor = "mfa" // Multiple factors
+)
+
+// IDTokenClaims represents the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	Nonce           string   `json:"nonce,omitempty"`
+	AuthTime        int64    `json:"auth_time,omitempty"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	AccessTokenHash string   `json:"at_hash,omitempty"`
+	SessionID       string   `json:"sid,omitempty"` // CentralAuth session, matched by front- and back-channel logout
+	ACR             string   `json:"acr,omitempty"` // Authentication context class the session achieved
+	AMR             []string `json:"amr,omitempty"` // Authentication methods the session was signed in with
+	StandardClaims
+	jwt.RegisteredClaims
+}
+
+// UserInfoClaims is the UserInfo response (OpenID Connect Core section 5.3.2).
+// Issuer and audience are only set when the response is signed.
+type UserInfoClaims struct {
+	StandardClaims
+	jwt.RegisteredClaims
+}
+
+// CreateIDToken signs an ID token. The caller sets issuer, subject and audience;
+// expiry, issue time and the at_hash of the accompanying access token are filled in here.
+func CreateIDToken(claims IDTokenClaims, accessToken string, expiry time.Duration) (string, error) {
+	now := time.Now()
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(expiry))
+	claims.IssuedAt = jwt.NewNumericDate(now)
+
+	key, err := keyStore.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+	if accessToken != "" {
+		claims.AccessTokenHash = AccessTokenHash(accessToken, key.Algorithm)
+	}
+
+	return signTokenWithKey(key, claims, "")
+}
+
+// AccessTokenHash computes the at_hash claim for an access token: the left half
+// of its hash, base64url encoded (OpenID Connect Core section 3.1.3.6).
+// The hash function is the one used by the ID token's signing algorithm.
+func AccessTokenHash(accessToken, alg string) string {
+	var sum []byte
+	if alg == SigningAlgEdDSA {
+		// Ed25519 uses SHA-512 internally
+		h := sha512.Sum512([]byte(accessToken))
+		sum = h[:]
+	} else {
+		h := sha256.Sum256([]byte(accessToken))
+		sum = h[:]
+	}
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+)
+
+// Key management algorithms accepted in encrypted JWTs sent to the server
+const (
+	KeyEncryptionAlgRSAOAEP    = "RSA-OAEP"
+	KeyEncryptionAlgRSAOAEP256 = "RSA-OAEP-256"
+)
+
+// KeyEncryptionAlgs lists the key management algorithms DecryptJWE accepts
+var KeyEncryptionAlgs = []string{KeyEncryptionAlgRSAOAEP, KeyEncryptionAlgRSAOAEP256}
+
+// ContentEncryptionAlgs lists the content encryption algorithms DecryptJWE accepts
+var ContentEncryptionAlgs = []string{"A128GCM", "A192GCM", "A256GCM"}
+
+// EncryptionKey is a key clients encrypt JWTs sent to the server with
+type EncryptionKey struct {
+	ID         string
+	PrivateKey *rsa.PrivateKey
+}
+
+// requestObjectKey decrypts encrypted request objects; nil when none is configured
+var requestObjectKey *EncryptionKey
+
+// InitRequestObjectEncryption loads the key clients may encrypt request objects with.
+// Without a configured key file request objects can only be signed.
+func InitRequestObjectEncryption(cfg config.OIDCConfig) error {
+	if cfg.RequestObjectEncryptionKeyFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.RequestObjectEncryptionKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read request object encryption key: %w", err)
+	}
+	privateKey, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return err
+	}
+	key, err := NewEncryptionKey(privateKey)
+	if err != nil {
+		return err
+	}
+	requestObjectKey = key
+	return nil
+}
+
+// GetRequestObjectEncryptionKey returns the key request objects are decrypted with, or nil
+func GetRequestObjectEncryptionKey() *EncryptionKey {
+	return requestObjectKey
+}
+
+// NewEncryptionKey wraps an RSA private key, deriving the key ID from its thumbprint
+func NewEncryptionKey(privateKey crypto.Signer) (*EncryptionKey, error) {
+	key, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("encryption keys must be RSA keys, got %T", privateKey)
+	}
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("RSA key must be at least 2048 bits")
+	}
+
+	jwk, err := PublicKeyToJWK(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := JWKThumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptionKey{ID: kid, PrivateKey: key}, nil
+}
+
+// PublicJWK returns the public part of the key for the JWKS
+func (k *EncryptionKey) PublicJWK() JWK {
+	// The thumbprint was computed from the same key, conversion cannot fail
+	jwk, _ := PublicKeyToJWK(&k.PrivateKey.PublicKey)
+	jwk.Use = "enc"
+	jwk.Kid = k.ID
+	jwk.Alg = KeyEncryptionAlgRSAOAEP256
+	return jwk
+}
+
+// jweHeader holds the protected header members DecryptJWE looks at
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Kid string `json:"kid"`
+	Zip string `json:"zip"`
+}
+
+// IsJWE reports whether a compact serialized token is encrypted rather than signed
+func IsJWE(token string) bool {
+	return strings.Count(token, ".") == 4
+}
+
+// DecryptJWE decrypts a compact serialized JWE (RFC 7516) encrypted to the key with RSA-OAEP
+// and AES-GCM, returning its plaintext. Compressed content is not supported.
+func DecryptJWE(token string, key *EncryptionKey) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, errors.New("malformed JWE")
+	}
+
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("malformed JWE: %w", err)
+		}
+		decoded[i] = b
+	}
+	protected, encryptedKey, iv, ciphertext, tag := decoded[0], decoded[1], decoded[2], decoded[3], decoded[4]
+
+	var header jweHeader
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return nil, fmt.Errorf("malformed JWE header: %w", err)
+	}
+	if header.Zip != "" {
+		return nil, fmt.Errorf("compression %q is not supported", header.Zip)
+	}
+	if header.Kid != "" && header.Kid != key.ID {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+
+	var oaepHash hash.Hash
+	switch header.Alg {
+	case KeyEncryptionAlgRSAOAEP:
+		oaepHash = sha1.New()
+	case KeyEncryptionAlgRSAOAEP256:
+		oaepHash = sha256.New()
+	default:
+		return nil, fmt.Errorf("key management algorithm %q is not supported", header.Alg)
+	}
+
+	var keySize int
+	switch header.Enc {
+	case "A128GCM":
+		keySize = 16
+	case "A192GCM":
+		keySize = 24
+	case "A256GCM":
+		keySize = 32
+	default:
+		return nil, fmt.Errorf("content encryption algorithm %q is not supported", header.Enc)
+	}
+
+	cek, err := rsa.DecryptOAEP(oaepHash, nil, key.PrivateKey, encryptedKey, nil)
+	if err != nil || len(cek) != keySize {
+		return nil, errors.New("could not decrypt the content encryption key")
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != gcm.NonceSize() || len(tag) != gcm.Overhead() {
+		return nil, errors.New("malformed JWE")
+	}
+
+	// The additional authenticated data is the encoded protected header (RFC 7516 section 5.2)
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, errors.New("could not decrypt the JWE")
+	}
+	return plaintext, nil
+}
@@ -2,21 +2,137 @@ package utils
 
 import (
 	"fmt"
-	"log"
+	"os"
+	"slices"
 	"time"
 
 	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// TokenTypeOAuthAccessToken is the typ header of access tokens issued to OAuth clients (RFC 9068)
+const TokenTypeOAuthAccessToken = "at+jwt"
+
+// sessionTokenAudience is the audience of the access tokens of CentralAuth sessions.
+// Tokens for other audiences are signed with the same keys and must not be accepted as session tokens.
+const sessionTokenAudience = "centralauth"
+
 var (
 	// jwtConfig holds the JWT configuration
 	jwtConfig config.JWTConfig
+
+	// keyStore holds the keys tokens are signed and verified with
+	keyStore = NewKeyStore()
 )
 
-// InitJWT initializes the JWT configuration
-func InitJWT(cfg config.JWTConfig) {
+// InitJWT initializes the JWT configuration and loads the signing key.
+// Without a key file for an asymmetric algorithm no key is active until
+// InitKeyRotation loads the keys shared through the database.
+func InitJWT(cfg config.JWTConfig) error {
 	jwtConfig = cfg
+
+	key, err := loadSigningKey(cfg)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		keyStore.Add(key, true)
+	}
+	return nil
+}
+
+// GetKeyStore returns the key store used to sign and verify tokens
+func GetKeyStore() *KeyStore {
+	return keyStore
+}
+
+// loadSigningKey builds the signing key for the configured algorithm.
+// Asymmetric keys are read from the configured PEM file. Without one it returns
+// nil, and the key rotator generates the first key and stores it in the database
+// so that every replica signs with it.
+func loadSigningKey(cfg config.JWTConfig) (*SigningKey, error) {
+	switch cfg.SigningAlgorithm {
+	case SigningAlgHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("JWT secret is not configured")
+		}
+		return &SigningKey{ID: legacyHS256KeyID, Algorithm: SigningAlgHS256, Secret: []byte(cfg.Secret)}, nil
+	case SigningAlgRS256, SigningAlgES256, SigningAlgEdDSA:
+		if cfg.PrivateKeyFile == "" {
+			return nil, nil
+		}
+
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+		}
+		privateKey, err := ParsePrivateKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return NewSigningKey(cfg.SigningAlgorithm, privateKey)
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", cfg.SigningAlgorithm)
+	}
+}
+
+// SignToken signs the claims with the active key and sets its kid header
+func SignToken(claims jwt.Claims) (string, error) {
+	key, err := keyStore.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+	return signTokenWithKey(key, claims, "")
+}
+
+// SignTokenWithAlgorithm signs the claims with the active key, which must use the given algorithm
+func SignTokenWithAlgorithm(claims jwt.Claims, alg string) (string, error) {
+	key, err := keyStore.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+	if key.Algorithm != alg {
+		return "", fmt.Errorf("no active signing key for %s, the active key uses %s", alg, key.Algorithm)
+	}
+	return signTokenWithKey(key, claims, "")
+}
+
+// signTokenWithKey signs the claims with the given key, setting the typ header when typ is not empty
+func signTokenWithKey(key *SigningKey, claims jwt.Claims, typ string) (string, error) {
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+
+	tokenString, err := token.SignedString(key.signKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// ParseToken parses a token and verifies it against the key named by its kid header
+func ParseToken(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// Tokens issued before key IDs were introduced were signed with the shared secret
+			kid = legacyHS256KeyID
+		}
+
+		key, ok := keyStore.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+
+		// The algorithm is bound to the key, never taken from the token alone
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verifyKey(), nil
+	}, opts...)
 }
 
 // AccessTokenClaims represents the claims for access tokens
@@ -42,74 +158,94 @@ func CreateAccessToken(claims AccessTokenClaims) (string, int, error) {
 	// Set the expiration time in the claims
 	expirationTime := time.Now().Add(time.Duration(expiry) * time.Second)
 	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{sessionTokenAudience},
 		ExpiresAt: jwt.NewNumericDate(expirationTime),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
-	// Create the token using the claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	log.Println(jwtConfig.Secret)
-	// Sign the token with the secret key
-	if jwtConfig.Secret == "" {
-		return "", 0, fmt.Errorf("JWT secret is not configured")
-	}
-
 	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(jwtConfig.Secret))
+	tokenString, err := SignToken(claims)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to sign token: %w", err)
+		return "", 0, err
 	}
 
 	return tokenString, expiry, nil
 }
 
-// GetTokenFromRequest extracts the JWT token from the Authorization header
-func GetUserIDFromAccessToken(tokenString string) (int64, error) {
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &AccessTokenClaims{}, func(token *jwt.Token) (any, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+// OAuthAccessTokenClaims represents the claims for access tokens issued to OAuth clients
+type OAuthAccessTokenClaims struct {
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope,omitempty"`
+	// Set on tokens obtained through token exchange, naming who acts for the subject
+	Actor *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ActorClaim is the act claim of a delegated token (RFC 8693 section 4.1).
+// A nested actor is one that acted for the subject earlier in the delegation chain.
+type ActorClaim struct {
+	Subject  string      `json:"sub"`
+	ClientID string      `json:"client_id,omitempty"`
+	Actor    *ActorClaim `json:"act,omitempty"`
+}
 
-		// Return the key for validation
-		return []byte(jwtConfig.Secret), nil
-	})
+// CreateOAuthAccessToken signs an access token for an OAuth client, typed at+jwt (RFC 9068).
+// The caller sets issuer, subject and audience; expiry, issue time and token ID are filled in here.
+func CreateOAuthAccessToken(claims OAuthAccessTokenClaims, expiry time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(expiry)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ID = uuid.New().String()
 
+	key, err := keyStore.ActiveKey()
 	if err != nil {
-		return 0, fmt.Errorf("invalid token: %w", err)
+		return "", time.Time{}, err
 	}
-
-	// Extract and validate claims
-	if claims, ok := token.Claims.(*AccessTokenClaims); ok && token.Valid {
-		return claims.UserID, nil
+	tokenString, err := signTokenWithKey(key, claims, TokenTypeOAuthAccessToken)
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
-	return 0, fmt.Errorf("invalid token claims")
+	return tokenString, expiresAt, nil
 }
 
-// ValidateToken validates and parses a JWT token
+// GetTokenFromRequest extracts the JWT token from the Authorization header
+func GetUserIDFromAccessToken(tokenString string) (int64, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ValidateToken validates and parses the access token of a CentralAuth session.
+// OAuth access tokens, ID tokens and the other tokens signed with the same keys are rejected.
 func ValidateToken(tokenString string) (*AccessTokenClaims, error) {
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &AccessTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		// Return the key for validation
-		return []byte(jwtConfig.Secret), nil
-	})
+	token, err := ParseToken(tokenString, &AccessTokenClaims{})
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
+	// Session tokens are plain JWTs, other tokens have their own typ
+	if typ, _ := token.Header["typ"].(string); typ != "" && typ != "JWT" {
+		return nil, fmt.Errorf("invalid token: unexpected token type %s", typ)
+	}
+
 	// Extract and validate claims
-	if claims, ok := token.Claims.(*AccessTokenClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*AccessTokenClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+
+	// ID tokens and the other tokens for clients have the client as audience. Session
+	// tokens issued before they had an audience have none; they are only accepted
+	// together with their row in access_tokens, see ValidateAccessTokenMiddleware.
+	if len(claims.Audience) > 0 && !slices.Contains(claims.Audience, sessionTokenAudience) {
+		return nil, fmt.Errorf("invalid token: not a session token")
 	}
 
-	return nil, fmt.Errorf("invalid token claims")
+	return claims, nil
 }
@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Satishcg12/CentralAuthV2/server/internal/config"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db"
+	"github.com/Satishcg12/CentralAuthV2/server/internal/db/sqlc"
+)
+
+// Signing key lifecycle states (signing_keys.state)
+const (
+	KeyStatePending  = "pending"  // Published in the JWKS, not yet signing
+	KeyStateActive   = "active"   // Signs new tokens
+	KeyStateRetiring = "retiring" // Still published until the tokens it signed have expired
+	KeyStateRetired  = "retired"  // No longer trusted
+)
+
+// KeyRotator keeps the key store in sync with the signing_keys table and
+// rotates keys on schedule. Every replica runs one; rotation steps are taken
+// under an advisory lock so only one replica performs each of them.
+type KeyRotator struct {
+	store           *db.Store
+	algorithm       string
+	encryptionKey   []byte
+	rotationPeriod  time.Duration
+	activationDelay time.Duration
+	syncInterval    time.Duration
+	tokenLifetime   time.Duration // Longest lifetime of a token signed by the server
+	legacySecret    string        // Shared secret tokens were signed with before key rotation
+}
+
+var (
+	// keyRotator is nil when the legacy HS256 algorithm is configured
+	keyRotator *KeyRotator
+)
+
+// InitKeyRotation loads the signing keys from the database and starts the rotation schedule.
+// When the table is empty, the key loaded by InitJWT becomes the first active key.
+func InitKeyRotation(ctx context.Context, store *db.Store, cfg *config.Config) error {
+	if cfg.JWT.SigningAlgorithm == SigningAlgHS256 {
+		log.Println("Signing key rotation is disabled with the legacy HS256 algorithm")
+		return nil
+	}
+
+	passphrase := cfg.JWT.KeyEncryptionKey
+	if passphrase == "" {
+		log.Println("Warning: JWT_KEY_ENCRYPTION_KEY is not set, encrypting signing keys with JWT_SECRET")
+		passphrase = cfg.JWT.Secret
+	}
+
+	r := &KeyRotator{
+		store:           store,
+		algorithm:       cfg.JWT.SigningAlgorithm,
+		encryptionKey:   DeriveEncryptionKey(passphrase),
+		rotationPeriod:  cfg.JWT.KeyRotationPeriod,
+		activationDelay: cfg.JWT.KeyActivationDelay,
+		syncInterval:    cfg.JWT.KeySyncInterval,
+		tokenLifetime: max(
+			time.Duration(cfg.JWT.ExpiryHours)*time.Hour,
+			cfg.OIDC.AccessTokenExpiry,
+			cfg.OIDC.IDTokenExpiry,
+		),
+		legacySecret: cfg.JWT.Secret,
+	}
+
+	if err := r.bootstrap(ctx); err != nil {
+		return err
+	}
+	if err := r.Sync(ctx); err != nil {
+		return err
+	}
+
+	keyRotator = r
+	keyStore.SetKeyLoader(r.loadKey)
+	go r.run()
+	return nil
+}
+
+// GetKeyRotator returns the key rotator, or nil when rotation is disabled
+func GetKeyRotator() *KeyRotator {
+	return keyRotator
+}
+
+// bootstrap stores the configured key as the active key if there is none yet,
+// generating one when no key file is configured. The legacy HS256 secret is
+// stored as a retiring key, so the tokens it signed stay valid after the upgrade.
+func (r *KeyRotator) bootstrap(ctx context.Context) error {
+	return r.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.LockSigningKeys(ctx); err != nil {
+			return err
+		}
+
+		if err := r.storeLegacyKey(ctx, q); err != nil {
+			return err
+		}
+
+		// Nothing to do when a key is active already
+		if _, err := q.GetActiveSigningKey(ctx); err != sql.ErrNoRows {
+			return err
+		}
+
+		key, err := keyStore.ActiveKey()
+		if err == nil {
+			_, err = q.GetSigningKeyByKid(ctx, key.ID)
+			if err == nil {
+				// The configured key was used before and has since been rotated out
+				key = nil
+			} else if err != sql.ErrNoRows {
+				return err
+			}
+		} else {
+			// No key file is configured
+			key = nil
+		}
+		if key == nil {
+			if key, err = GenerateSigningKey(r.algorithm); err != nil {
+				return err
+			}
+		}
+
+		if _, err := r.createKey(ctx, q, key); err != nil {
+			return err
+		}
+		if _, err := q.ActivateSigningKey(ctx, key.ID); err != nil {
+			return err
+		}
+		log.Printf("Signing key %s stored as the active key", key.ID)
+		return nil
+	})
+}
+
+// storeLegacyKey stores the legacy HS256 secret as a retiring key, unless it was stored
+// before. It is retired once the tokens it signed have expired, or manually with Retire.
+func (r *KeyRotator) storeLegacyKey(ctx context.Context, q *sqlc.Queries) error {
+	if r.legacySecret == "" {
+		return nil
+	}
+	if _, err := q.GetSigningKeyByKid(ctx, legacyHS256KeyID); err != sql.ErrNoRows {
+		return err
+	}
+
+	encrypted, err := Encrypt(r.encryptionKey, []byte(r.legacySecret))
+	if err != nil {
+		return err
+	}
+	if _, err := q.CreateLegacySigningKey(ctx, sqlc.CreateLegacySigningKeyParams{
+		Kid:        legacyHS256KeyID,
+		Algorithm:  SigningAlgHS256,
+		PrivateKey: encrypted,
+	}); err != nil {
+		return err
+	}
+	log.Println("Legacy HS256 key stored as retiring, tokens it signed stay valid until they expire")
+	return nil
+}
+
+// run periodically advances the rotation schedule and reloads the keys
+func (r *KeyRotator) run() {
+	ticker := time.NewTicker(r.syncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), r.syncInterval)
+		if err := r.rotateIfDue(ctx); err != nil {
+			log.Printf("Failed to rotate signing keys: %v", err)
+		}
+		if err := r.Sync(ctx); err != nil {
+			log.Printf("Failed to sync signing keys: %v", err)
+		}
+		cancel()
+	}
+}
+
+// rotateIfDue moves keys through their lifecycle:
+// retiring keys are retired once their tokens have expired, a pending key is
+// activated after the activation delay, and a new pending key is created
+// ahead of the end of the active key's rotation period.
+func (r *KeyRotator) rotateIfDue(ctx context.Context) error {
+	return r.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.LockSigningKeys(ctx); err != nil {
+			return err
+		}
+
+		retired, err := q.RetireExpiredSigningKeys(ctx, time.Now().Add(-r.tokenLifetime))
+		if err != nil {
+			return err
+		}
+		for _, key := range retired {
+			log.Printf("Signing key %s retired", key.Kid)
+		}
+
+		pending, err := q.GetPendingSigningKey(ctx)
+		if err == nil {
+			if time.Since(pending.CreatedAt) < r.activationDelay {
+				return nil
+			}
+			return r.activate(ctx, q, pending.Kid)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		active, err := q.GetActiveSigningKey(ctx)
+		if err != nil {
+			return err
+		}
+		if time.Since(active.ActivatedAt.Time) < r.rotationPeriod-r.activationDelay {
+			return nil
+		}
+
+		key, err := GenerateSigningKey(r.algorithm)
+		if err != nil {
+			return err
+		}
+		if _, err := r.createKey(ctx, q, key); err != nil {
+			return err
+		}
+		log.Printf("Signing key %s created, activating in %s", key.ID, r.activationDelay)
+		return nil
+	})
+}
+
+// Rotate makes a new key active immediately. A pending key of the configured
+// algorithm is activated ahead of time, relying parties may already have it from
+// the JWKS; otherwise a key is created. Any other pending key is retired, so
+// pending keys do not pile up. The previous active key keeps verifying the
+// tokens it signed until they expire.
+func (r *KeyRotator) Rotate(ctx context.Context) (sqlc.SigningKey, error) {
+	var activated sqlc.SigningKey
+	err := r.store.ExecTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.LockSigningKeys(ctx); err != nil {
+			return err
+		}
+
+		kid := ""
+		pending, err := q.GetPendingSigningKey(ctx)
+		if err == nil && pending.Algorithm == r.algorithm {
+			kid = pending.Kid
+		} else if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if kid == "" {
+			key, err := GenerateSigningKey(r.algorithm)
+			if err != nil {
+				return err
+			}
+			if _, err := r.createKey(ctx, q, key); err != nil {
+				return err
+			}
+			kid = key.ID
+		}
+
+		if err := q.RetirePendingSigningKeys(ctx, kid); err != nil {
+			return err
+		}
+		if err := q.RetireActiveSigningKey(ctx); err != nil {
+			return err
+		}
+		activated, err = q.ActivateSigningKey(ctx, kid)
+		return err
+	})
+	if err != nil {
+		return sqlc.SigningKey{}, err
+	}
+
+	log.Printf("Signing key %s activated by rotation", activated.Kid)
+	return activated, r.Sync(ctx)
+}
+
+// Retire immediately stops trusting a pending or retiring key, e.g. when it was compromised.
+// It returns sql.ErrNoRows if there is no such key; the active key must be rotated out first.
+func (r *KeyRotator) Retire(ctx context.Context, kid string) (sqlc.SigningKey, error) {
+	key, err := r.store.RetireSigningKey(ctx, kid)
+	if err != nil {
+		return sqlc.SigningKey{}, err
+	}
+
+	log.Printf("Signing key %s retired manually", key.Kid)
+	return key, r.Sync(ctx)
+}
+
+// List returns all keys in the database, newest first
+func (r *KeyRotator) List(ctx context.Context) ([]sqlc.SigningKey, error) {
+	return r.store.ListSigningKeys(ctx)
+}
+
+// Sync replaces the contents of the key store with the keys in the database
+func (r *KeyRotator) Sync(ctx context.Context) error {
+	rows, err := r.store.ListVerificationSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*SigningKey, 0, len(rows))
+	activeID := ""
+	for _, row := range rows {
+		key, err := r.decodeKey(row)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", row.Kid, err)
+		}
+		keys = append(keys, key)
+		if row.State == KeyStateActive {
+			activeID = key.ID
+		}
+	}
+	if activeID == "" {
+		return fmt.Errorf("no active signing key in the database")
+	}
+
+	keyStore.Replace(keys, activeID)
+	return nil
+}
+
+// loadKey looks up a key another replica may have created since the last sync
+func (r *KeyRotator) loadKey(kid string) (*SigningKey, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	row, err := r.store.GetSigningKeyByKid(ctx, kid)
+	if err != nil || row.State == KeyStateRetired {
+		return nil, false
+	}
+	key, err := r.decodeKey(row)
+	if err != nil {
+		log.Printf("Failed to load signing key %s: %v", kid, err)
+		return nil, false
+	}
+	return key, true
+}
+
+// activate makes a pending key the active one, moving the current active key to retiring
+func (r *KeyRotator) activate(ctx context.Context, q *sqlc.Queries, kid string) error {
+	if err := q.RetireActiveSigningKey(ctx); err != nil {
+		return err
+	}
+	if _, err := q.ActivateSigningKey(ctx, kid); err != nil {
+		return err
+	}
+	log.Printf("Signing key %s activated", kid)
+	return nil
+}
+
+// createKey stores a key in the pending state with its private key encrypted
+func (r *KeyRotator) createKey(ctx context.Context, q *sqlc.Queries, key *SigningKey) (sqlc.SigningKey, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return sqlc.SigningKey{}, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	encrypted, err := Encrypt(r.encryptionKey, der)
+	if err != nil {
+		return sqlc.SigningKey{}, err
+	}
+
+	return q.CreateSigningKey(ctx, sqlc.CreateSigningKeyParams{
+		Kid:        key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: encrypted,
+	})
+}
+
+// decodeKey decrypts a stored key
+func (r *KeyRotator) decodeKey(row sqlc.SigningKey) (*SigningKey, error) {
+	der, err := Decrypt(r.encryptionKey, row.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if row.Algorithm == SigningAlgHS256 {
+		// The legacy key is stored as the shared secret itself
+		return &SigningKey{ID: row.Kid, Algorithm: row.Algorithm, Secret: der}, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	privateKey, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+
+	return &SigningKey{ID: row.Kid, Algorithm: row.Algorithm, PrivateKey: privateKey}, nil
+}
@@ -0,0 +1,480 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token signing algorithms supported by the key store
+const (
+	SigningAlgHS256 = "HS256" // Legacy shared secret, never published in the JWKS
+	SigningAlgRS256 = "RS256"
+	SigningAlgES256 = "ES256"
+	SigningAlgEdDSA = "EdDSA"
+)
+
+// legacyHS256KeyID is the kid used for tokens signed with the shared JWT secret
+const legacyHS256KeyID = "hs256"
+
+// SigningKey is a key the server signs tokens with
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer // Set for asymmetric algorithms
+	Secret     []byte        // Set for HS256
+}
+
+// Method returns the JWT signing method for the key
+func (k *SigningKey) Method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case SigningAlgRS256:
+		return jwt.SigningMethodRS256
+	case SigningAlgES256:
+		return jwt.SigningMethodES256
+	case SigningAlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// signKey returns the key material passed to jwt when signing
+func (k *SigningKey) signKey() any {
+	if k.Algorithm == SigningAlgHS256 {
+		return k.Secret
+	}
+	return k.PrivateKey
+}
+
+// verifyKey returns the key material passed to jwt when verifying
+func (k *SigningKey) verifyKey() any {
+	if k.Algorithm == SigningAlgHS256 {
+		return k.Secret
+	}
+	return k.PrivateKey.Public()
+}
+
+// JWK is a JSON Web Key (RFC 7517) holding a public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public half of the key as a JWK.
+// It reports false for symmetric keys, which must never be published.
+func (k *SigningKey) PublicJWK() (JWK, bool) {
+	if k.Algorithm == SigningAlgHS256 {
+		return JWK{}, false
+	}
+	jwk, err := PublicKeyToJWK(k.PrivateKey.Public())
+	if err != nil {
+		return JWK{}, false
+	}
+	jwk.Use = "sig"
+	jwk.Kid = k.ID
+	jwk.Alg = k.Algorithm
+	return jwk, true
+}
+
+// PublicKeyToJWK converts an RSA, P-256 or Ed25519 public key to a JWK
+func PublicKeyToJWK(publicKey crypto.PublicKey) (JWK, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("unsupported elliptic curve %s", key.Curve.Params().Name)
+		}
+		ecdh, err := key.ECDH()
+		if err != nil {
+			return JWK{}, fmt.Errorf("invalid EC public key: %w", err)
+		}
+		// Uncompressed point: 0x04 || X || Y, 32 bytes each for P-256
+		point := ecdh.Bytes()
+		return JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// PublicKey converts a JWK to an RSA, EC (P-256, P-384 or P-521) or Ed25519 public key
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := func(name, value string) ([]byte, error) {
+		if value == "" {
+			return nil, fmt.Errorf("missing %s member", name)
+		}
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s member: %w", name, err)
+		}
+		return b, nil
+	}
+
+	switch j.Kty {
+	case "RSA":
+		n, err := decode("n", j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e", j.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+		return key, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch j.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported elliptic curve %s", j.Crv)
+		}
+		x, err := decode("x", j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y", j.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("invalid %s point", j.Crv)
+		}
+		// Uncompressed point: 0x04 || X || Y, parsed by crypto/ecdh to check it is on the curve
+		point := append([]byte{4}, append(x, y...)...)
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid %s point: %w", j.Crv, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", j.Crv)
+		}
+		x, err := decode("x", j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", j.Kty)
+	}
+}
+
+// JWKThumbprint computes the RFC 7638 thumbprint of a JWK, used as key ID
+func JWKThumbprint(jwk JWK) (string, error) {
+	// Only the required members, in lexicographic order, without whitespace
+	var members map[string]string
+	switch jwk.Kty {
+	case "RSA":
+		members = map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N}
+	case "EC":
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X, "y": jwk.Y}
+	case "OKP":
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+
+	// encoding/json sorts map keys, which gives the canonical form
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// NewSigningKey wraps a private key, deriving the key ID from its thumbprint
+func NewSigningKey(alg string, privateKey crypto.Signer) (*SigningKey, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if alg != SigningAlgRS256 {
+			return nil, fmt.Errorf("RSA key cannot be used with %s", alg)
+		}
+		if key.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must be at least 2048 bits")
+		}
+	case *ecdsa.PrivateKey:
+		if alg != SigningAlgES256 || key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 requires a P-256 EC key")
+		}
+	case ed25519.PrivateKey:
+		if alg != SigningAlgEdDSA {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with %s", alg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+
+	jwk, err := PublicKeyToJWK(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	kid, err := JWKThumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: kid, Algorithm: alg, PrivateKey: privateKey}, nil
+}
+
+// GenerateSigningKey creates a new private key for the given algorithm
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch alg {
+	case SigningAlgRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case SigningAlgES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case SigningAlgEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("cannot generate a key for algorithm %s", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", alg, err)
+	}
+
+	return NewSigningKey(alg, privateKey)
+}
+
+// ParsePrivateKeyPEM parses a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) PEM private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// KeyStore holds the keys used to sign and verify tokens
+type KeyStore struct {
+	mu       sync.RWMutex
+	keys     map[string]*SigningKey
+	activeID string
+
+	// loadKey is consulted for key IDs the store does not know yet,
+	// e.g. a key another replica activated since the last sync
+	loadKey func(kid string) (*SigningKey, bool)
+	// lookups records when loadKey was last consulted for each key ID it did not find.
+	// A key ID is looked up at most once per keyLoadInterval, so tokens repeating a
+	// made-up key ID cannot each cost a query, while other key IDs are still looked up.
+	lookups map[string]time.Time
+}
+
+const (
+	// keyLoadInterval is the minimum time between lookups of the same unknown key ID
+	keyLoadInterval = time.Second
+	// maxKeyLookups bounds the key IDs remembered in lookups; older entries are dropped when it is reached
+	maxKeyLookups = 1024
+)
+
+// NewKeyStore creates an empty key store
+func NewKeyStore() *KeyStore {
+	return &KeyStore{keys: make(map[string]*SigningKey), lookups: make(map[string]time.Time)}
+}
+
+// Add adds a key to the store. An active key is used for signing new tokens.
+func (s *KeyStore) Add(key *SigningKey, active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+	if active {
+		s.activeID = key.ID
+	}
+}
+
+// Replace swaps the contents of the store for the given keys
+func (s *KeyStore) Replace(keys []*SigningKey, activeID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = make(map[string]*SigningKey, len(keys))
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+	s.activeID = activeID
+	// Key IDs missed before the sync are looked up again
+	clear(s.lookups)
+}
+
+// SetKeyLoader sets the function used to look up unknown key IDs
+func (s *KeyStore) SetKeyLoader(loadKey func(kid string) (*SigningKey, bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loadKey = loadKey
+}
+
+// ActiveKey returns the key used for signing new tokens
+func (s *KeyStore) ActiveKey() (*SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[s.activeID]
+	if !ok {
+		return nil, fmt.Errorf("no active signing key configured")
+	}
+	return key, nil
+}
+
+// SigningAlgorithm returns the algorithm of the active key, or an empty string when there is none
+func (s *KeyStore) SigningAlgorithm() string {
+	key, err := s.ActiveKey()
+	if err != nil {
+		return ""
+	}
+	return key.Algorithm
+}
+
+// Key returns the key with the given key ID
+func (s *KeyStore) Key(kid string) (*SigningKey, bool) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	loadKey := s.loadKey
+	s.mu.RUnlock()
+
+	if ok || loadKey == nil {
+		return key, ok
+	}
+
+	s.mu.Lock()
+	if time.Since(s.lookups[kid]) < keyLoadInterval {
+		s.mu.Unlock()
+		return nil, false
+	}
+	if len(s.lookups) >= maxKeyLookups {
+		for id, at := range s.lookups {
+			if time.Since(at) >= keyLoadInterval {
+				delete(s.lookups, id)
+			}
+		}
+	}
+	s.lookups[kid] = time.Now()
+	s.mu.Unlock()
+
+	key, ok = loadKey(kid)
+	if ok {
+		s.Add(key, false)
+	}
+	return key, ok
+}
+
+// JWKS returns the public keys of all asymmetric keys in the store
+func (s *KeyStore) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		if jwk, ok := key.PublicJWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// Algorithms returns the distinct signing algorithms of the keys in the store.
+// The legacy HS256 key is only included while it signs new tokens.
+func (s *KeyStore) Algorithms() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	algs := make([]string, 0)
+	for _, key := range s.keys {
+		if key.Algorithm == SigningAlgHS256 && key.ID != s.activeID {
+			continue
+		}
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
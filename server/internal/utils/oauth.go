@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Client authentication methods at the token endpoint (OpenID Connect Core section 9)
+const (
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+	AuthMethodClientSecretJWT   = "client_secret_jwt" // Assertion signed with the client secret
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"   // Assertion signed with a registered public key
+	AuthMethodNone              = "none"              // Public clients, identified by client_id only
+)
+
+type OAuthErrorCode string
+
+// OAuth 2.0 / OpenID Connect error codes (RFC 6749 section 4.1.2.1 and 5.2)
+const (
+	OAuthErrorInvalidRequest          OAuthErrorCode = "invalid_request"
+	OAuthErrorInvalidClient           OAuthErrorCode = "invalid_client"
+	OAuthErrorInvalidGrant            OAuthErrorCode = "invalid_grant"
+	OAuthErrorInvalidScope            OAuthErrorCode = "invalid_scope"
+	OAuthErrorUnauthorizedClient      OAuthErrorCode = "unauthorized_client"
+	OAuthErrorUnsupportedGrantType    OAuthErrorCode = "unsupported_grant_type"
+	OAuthErrorUnsupportedResponseType OAuthErrorCode = "unsupported_response_type"
+	OAuthErrorAccessDenied            OAuthErrorCode = "access_denied"
+	OAuthErrorServerError             OAuthErrorCode = "server_error"
+
+	// Bearer token errors (RFC 6750 section 3.1)
+	OAuthErrorInvalidToken      OAuthErrorCode = "invalid_token"
+	OAuthErrorInsufficientScope OAuthErrorCode = "insufficient_scope"
+
+	// Authentication errors (OpenID Connect Core section 3.1.2.6)
+	OAuthErrorLoginRequired   OAuthErrorCode = "login_required"
+	OAuthErrorConsentRequired OAuthErrorCode = "consent_required"
+	// The requested authentication context cannot be satisfied
+	// (OpenID Connect Core Unmet Authentication Requirements 1.0)
+	OAuthErrorUnmetAuthenticationRequirements OAuthErrorCode = "unmet_authentication_requirements"
+
+	// Device access token errors (RFC 8628 section 3.5)
+	OAuthErrorAuthorizationPending OAuthErrorCode = "authorization_pending"
+	OAuthErrorSlowDown             OAuthErrorCode = "slow_down"
+	OAuthErrorExpiredToken         OAuthErrorCode = "expired_token"
+
+	// The request object or request_uri of an authorization request is invalid (RFC 9101 section 6.2)
+	OAuthErrorInvalidRequestObject OAuthErrorCode = "invalid_request_object"
+	OAuthErrorInvalidRequestURI    OAuthErrorCode = "invalid_request_uri"
+
+	// A token cannot be issued for the requested audience or resource (RFC 8693 section 2.2.2)
+	OAuthErrorInvalidTarget OAuthErrorCode = "invalid_target"
+
+	// Dynamic client registration errors (RFC 7591 section 3.2.2)
+	OAuthErrorInvalidRedirectURI    OAuthErrorCode = "invalid_redirect_uri"
+	OAuthErrorInvalidClientMetadata OAuthErrorCode = "invalid_client_metadata"
+)
+
+// OAuthErrorResponse is the error body defined by RFC 6749 section 5.2
+type OAuthErrorResponse struct {
+	Error            OAuthErrorCode `json:"error"`
+	ErrorDescription string         `json:"error_description,omitempty"`
+}
+
+// RespondWithOAuthError sends an RFC 6749 error response.
+// OAuth endpoints use this instead of RespondWithError because relying party
+// libraries expect the standard error shape.
+func RespondWithOAuthError(c echo.Context, statusCode StatusCode, errorCode OAuthErrorCode, description string) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+	return c.JSON(int(statusCode), OAuthErrorResponse{
+		Error:            errorCode,
+		ErrorDescription: description,
+	})
+}
+
+// RespondWithBearerError sends an RFC 6750 error for a protected resource with
+// the matching WWW-Authenticate challenge. Without an error code, as when no
+// token was presented, only the challenge is sent.
+func RespondWithBearerError(c echo.Context, statusCode StatusCode, errorCode OAuthErrorCode, description string) error {
+	challenge := `Bearer realm="oauth2"`
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error="%s"`, errorCode)
+		if description != "" {
+			challenge += fmt.Sprintf(`, error_description="%s"`, strings.ReplaceAll(description, `"`, `'`))
+		}
+	}
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+
+	if errorCode == "" {
+		return c.NoContent(int(statusCode))
+	}
+	return RespondWithOAuthError(c, statusCode, errorCode, description)
+}
+
+// RedirectWithOAuthError redirects the user agent back to the client with an
+// error in the query string, as required once the redirect URI has been validated
+func RedirectWithOAuthError(c echo.Context, redirectURI string, errorCode OAuthErrorCode, description, state string) error {
+	params := url.Values{}
+	params.Set("error", string(errorCode))
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	return c.Redirect(http.StatusFound, AppendQuery(redirectURI, params))
+}
+
+// AppendQuery adds the given parameters to a URL, keeping any query it already has
+func AppendQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// ParseScope splits a space-delimited scope string into unique scope values
+func ParseScope(scope string) []string {
+	scopes := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, s := range strings.Fields(scope) {
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// PKCE code challenge methods (RFC 7636)
+const (
+	CodeChallengeMethodPlain = "plain"
+	CodeChallengeMethodS256  = "S256"
+)
+
+// codeVerifierPattern matches the code_verifier ABNF from RFC 7636 section 4.1
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// IsValidCodeVerifier checks the length and character set of a PKCE code verifier
+func IsValidCodeVerifier(verifier string) bool {
+	return codeVerifierPattern.MatchString(verifier)
+}
+
+// VerifyCodeChallenge checks a PKCE code verifier against the stored challenge
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+	if !IsValidCodeVerifier(verifier) {
+		return false
+	}
+
+	var computed string
+	switch method {
+	case CodeChallengeMethodS256:
+		sum := sha256.Sum256([]byte(verifier))
+		computed = base64.RawURLEncoding.EncodeToString(sum[:])
+	case CodeChallengeMethodPlain:
+		computed = verifier
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
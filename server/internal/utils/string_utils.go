@@ -1,6 +1,8 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -177,3 +179,17 @@ func GenerateRandomString(length int) (string, error) {
 	}
 	return string(b), nil
 }
+
+// GenerateSecureToken generates a URL-safe random token from a cryptographically
+// secure source. Use it for values that grant access such as authorization codes.
+func GenerateSecureToken(byteLength int) (string, error) {
+	if byteLength <= 0 {
+		return "", fmt.Errorf("length must be greater than 0")
+	}
+
+	b := make([]byte, byteLength)
+	if _, err := cryptorand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
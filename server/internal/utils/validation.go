@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 
@@ -51,8 +52,37 @@ func NewValidator() *CustomValidator {
 
 // RegisterCustomValidations registers any custom validation functions
 func RegisterCustomValidations(v *validator.Validate) {
-	// Add custom validations here if needed
-	// Example: v.RegisterValidation("custom_tag", customValidationFunc)
+	v.RegisterValidation("redirect_uri", validateRedirectURI)
+	v.RegisterValidation("web_origin", validateWebOrigin)
+}
+
+// validateRedirectURI accepts the values IsValidRedirectURI accepts
+func validateRedirectURI(fl validator.FieldLevel) bool {
+	return IsValidRedirectURI(fl.Field().String())
+}
+
+// IsValidRedirectURI accepts absolute URIs without a fragment (RFC 6749 section 3.1.2).
+// Custom schemes are allowed for native apps.
+func IsValidRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Fragment != "" || strings.Contains(uri, "#") {
+		return false
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		return u.Host != ""
+	}
+	return true
+}
+
+// validateWebOrigin accepts an origin as browsers send it: scheme, host and
+// optional port, without a path or trailing slash
+func validateWebOrigin(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") &&
+		u.Host != "" && u.User == nil && u.Path == "" && u.RawQuery == "" && !u.ForceQuery && u.Fragment == ""
 }
 
 // FormatValidationErrors formats validation errors into a user-friendly map
@@ -73,6 +103,10 @@ func FormatValidationErrors(validationErrs validator.ValidationErrors) map[strin
 			message = fmt.Sprintf("%s must be at least %s characters long", field, e.Param())
 		case "max":
 			message = fmt.Sprintf("%s must be at most %s characters long", field, e.Param())
+		case "redirect_uri":
+			message = fmt.Sprintf("%s must contain absolute URIs without a fragment", field)
+		case "web_origin":
+			message = fmt.Sprintf("%s must contain origins such as https://app.example.com, without a path", field)
 		case "eqfield":
 			fieldName := strings.ToLower(e.Param())
 			message = fmt.Sprintf("%s must be equal to %s", field, fieldName)